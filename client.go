@@ -1,16 +1,42 @@
 package ghostmates
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 type (
 	Client struct {
-		customer_id string
-		client      *http.Client
+		customer_id    string
+		credentials    CredentialsProvider // consulted for the api key on every request
+		base           *url.URL            // scheme, host and optional path prefix requests are sent to
+		version        string              // X-Postmates-Version header
+		manifest_items bool                // send manifest items as manifest_items rather than text
+		user_agent     string              // User-Agent header, go default when empty
+		transport      http.RoundTripper   // underlying transport requests are sent on
+		middleware     []Middleware        // wrapped around transport, first registered sees requests first
+		timeout        time.Duration
+		retry          RetryPolicy
+		now            func() time.Time // clock quotes are checked against
+		log            logging
+		max_pages      int // cap on pages fetched by a single delivery scan
+		mode           Mode
+		mode_mu        sync.Mutex
+		mode_ok        bool               // a response has shown the account is in mode
+		mode_err       *ModeMismatchError // first live mode mismatch, fails every later call
+		client         *http.Client
+
+		read_limiter  *RateLimiter
+		write_limiter *RateLimiter
 	}
 
 	DeliverySpot struct {
@@ -61,11 +87,25 @@ type (
 		Name            string   `json:"name"`
 		Notes           string   `json:"notes"`
 		PhoneNumber     string   `json:"phone_number"`
+
+		Verification *Verification `json:"verification,omitempty"` // Proof collected at this spot
+	}
+
+	// Verification holds the proof a courier collected at a spot.
+	Verification struct {
+		Signature *VerificationImage `json:"signature,omitempty"`
+		Picture   *VerificationImage `json:"picture,omitempty"`
+	}
+
+	VerificationImage struct {
+		ImageURL string `json:"image_url"`
 	}
 
 	Manifest struct {
 		Description string `json:"description"` // A free form body describing the package
 		Reference   string `json:"reference"`   // Developer provided identifier for the courier to reference when picking up the package
+
+		Items []ManifestItem `json:"items,omitempty"` // Itemized contents, folded into the description unless the client was created WithManifestItems
 	}
 
 	RelatedDelivery struct {
@@ -76,7 +116,7 @@ type (
 	Delivery struct {
 		Kind                     string            `json:"kind"`
 		ID                       string            `json:"id"`
-		Status                   string            `json:"status"`
+		Status                   Status            `json:"status"`
 		Created                  *time.Time        `json:"created"`
 		Updated                  *time.Time        `json:"updated"`
 		DropoffEta               *time.Time        `json:"dropoff_eta"`                 // Estimated time the courier will arrive at the dropoff location.
@@ -110,14 +150,7 @@ type (
 const (
 	APIVersion = "20150519"
 	APIHost    = "api.postmates.com"
-
-	StatusPending        = "pending"         // We've accepted the delivery and will be assigning it to a courier.
-	StatusPickup         = "pickup"          // Courier is assigned and is en route to pick up the items
-	StatusPickupComplete = "pickup_complete" // Courier has picked up the items
-	StatusDropoff        = "dropoff"         // Courier is moving towards the dropoff
-	StatusCanceled       = "canceled"        // Items won't be delivered. Deliveries are either canceled by the customer or by our customer service team.
-	StatusDelivered      = "delivered"       // Items were delivered successfully.
-	StatusReturned       = "returned"        // The delivery was canceled and a new job created to return items to sender. (See related_deliveries in delivery object.)
+	APIBaseURL = "https://" + APIHost
 
 	VehicleBicycle    = "bicycle"
 	VehicleCar        = "car"
@@ -138,6 +171,12 @@ const (
 	AllDeliveries = -1
 )
 
+// Expired reports whether the quote will no longer be accepted at now.
+// A nil quote or one without an expiry never expires.
+func (dq *DeliveryQuote) Expired(now time.Time) bool {
+	return dq != nil && dq.Expires != nil && !now.Before(*dq.Expires)
+}
+
 func NewManifest(description, reference string) *Manifest {
 	return &Manifest{
 		Description: description,
@@ -154,26 +193,52 @@ func NewDeliverySpot(name, address, phonenumber string) *DeliverySpot {
 	}
 }
 
+// NewClient returns a client that sends each request once, as it always has.
+// Use NewClientWithOptions for retries and everything else.
 func NewClient(customer_id, api_key string, timeout time.Duration) *Client {
+	// neither option can fail so neither can we
+	c, _ := NewClientWithOptions(customer_id, api_key, WithTimeout(timeout), WithRetryPolicy(NoRetries))
+	return c
+}
 
-	return &Client{
+// NewClientWithOptions returns a client for the given account configured by opts.
+// Without options requests go to APIBaseURL over http.DefaultTransport with no
+// timeout, and transient failures are retried per DefaultRetryPolicy.
+func NewClientWithOptions(customer_id, api_key string, opts ...Option) (*Client, error) {
+
+	c := &Client{
 		customer_id: customer_id,
-		client: &http.Client{
-			Transport: postmatesTransport(func(req *http.Request) (*http.Response, error) {
-				req.Host = APIHost
-				req.URL.Host = APIHost
-				req.URL.Scheme = "https"
-				req.Header.Set("X-Postmates-Version", APIVersion)
-				req.SetBasicAuth(api_key, "")
-				return http.DefaultTransport.RoundTrip(req)
-			}),
-			Timeout: timeout,
-		},
+		credentials: StaticCredentials(api_key),
+		base:        &url.URL{Scheme: "https", Host: APIHost},
+		version:     APIVersion,
+		transport:   http.DefaultTransport,
+		retry:       DefaultRetryPolicy,
+		now:         time.Now,
+		log:         defaultLogging(),
+		max_pages:   DefaultMaxPages,
+	}
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
 	}
 
+	// the postmates layer runs first so middleware sees requests as they go out
+	c.client = &http.Client{
+		Transport: chain(c.transport, append([]Middleware{c.postmatesMiddleware}, c.middleware...)...),
+		Timeout:   c.timeout,
+	}
+
+	return c, nil
+
 }
 
 func (c *Client) GetQuote(pickup_address, dropoff_address string) (*DeliveryQuote, error) {
+	return c.GetQuoteContext(context.Background(), pickup_address, dropoff_address)
+}
+
+func (c *Client) GetQuoteContext(ctx context.Context, pickup_address, dropoff_address string) (*DeliveryQuote, error) {
 
 	// POST /v1/customers/:customer_id/delivery_quotes
 
@@ -182,34 +247,73 @@ func (c *Client) GetQuote(pickup_address, dropoff_address string) (*DeliveryQuot
 
 	// You'll receive a DeliveryQuote response.
 
-	resp, err := c.client.PostForm(
-		"/v1/customers/"+url.QueryEscape(c.customer_id)+"/delivery_quotes",
-		url.Values{
-			"pickup_address":  []string{pickup_address},
-			"dropoff_address": []string{dropoff_address},
-		},
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, NewError(resp)
+	// sent as is, GetQuoteRequest validates locally first
+	form := url.Values{
+		"pickup_address":  []string{pickup_address},
+		"dropoff_address": []string{dropoff_address},
 	}
 
 	dq := &DeliveryQuote{}
-	if err := json.NewDecoder(resp.Body).Decode(dq); err != nil {
-		return nil, err
+	if err := c.do(ctx, readCall, "POST", c.customerPath("delivery_quotes"), form, dq); err != nil {
+		return nil, withParamFields(err, quoteParamFields)
 	}
 
 	return dq, nil
 
 }
 
+// Deprecated: CreateDelivery discards the created delivery, use PlaceDelivery.
 func (c *Client) CreateDelivery(manifest *Manifest, pickup, dropoff *DeliverySpot, quote *DeliveryQuote) error {
+	return c.CreateDeliveryContext(context.Background(), manifest, pickup, dropoff, quote)
+}
+
+// Deprecated: CreateDeliveryContext discards the created delivery, use PlaceDeliveryContext.
+// Unlike PlaceDeliveryContext every field is sent as given, empty or not,
+// and only the quote's expiry is checked locally first.  An expired quote
+// fails with ErrQuoteExpired, it is never requoted.
+func (c *Client) CreateDeliveryContext(ctx context.Context, manifest *Manifest, pickup, dropoff *DeliverySpot, quote *DeliveryQuote) error {
+
+	// the api would refuse it anyway
+	if quote.Expired(c.now()) {
+		return fmt.Errorf("%w: %s expired at %s", ErrQuoteExpired, quote.ID, quote.Expires.Format(time.RFC3339))
+	}
+
+	var quote_id string
+	if quote != nil {
+		quote_id = quote.ID
+	}
+
+	form := url.Values{
+		"manifest":              []string{manifest.Text()},
+		"manifest_reference":    []string{manifest.Reference},
+		"pickup_name":           []string{pickup.Name},
+		"pickup_address":        []string{pickup.Address},
+		"pickup_phone_number":   []string{pickup.PhoneNumber},
+		"pickup_business_name":  []string{pickup.BusinessName},
+		"pickup_notes":          []string{pickup.Notes},
+		"dropoff_name":          []string{dropoff.Name},
+		"dropoff_address":       []string{dropoff.Address},
+		"dropoff_phone_number":  []string{dropoff.PhoneNumber},
+		"dropoff_business_name": []string{dropoff.BusinessName},
+		"dropoff_notes":         []string{dropoff.Notes},
+		"quote_id":              []string{quote_id},
+	}
+
+	return c.do(ctx, writeCall, "POST", c.customerPath("deliveries"), form, &Delivery{})
+
+}
+
+// PlaceDelivery creates a delivery and returns it as the api created it.
+// The request is validated locally and empty fields are left out, see
+// PlaceDeliveryRequest.
+func (c *Client) PlaceDelivery(manifest *Manifest, pickup, dropoff *DeliverySpot, quote *DeliveryQuote) (*Delivery, error) {
+	return c.PlaceDeliveryContext(context.Background(), manifest, pickup, dropoff, quote)
+}
+
+func (c *Client) PlaceDeliveryContext(ctx context.Context, manifest *Manifest, pickup, dropoff *DeliverySpot, quote *DeliveryQuote) (*Delivery, error) {
 
 	// POST /v1/customers/:customer_id/deliveries
+	// Returns: Delivery Object
 
 	// manifest="a box of kittens"
 	// manifest_reference="Optional reference that identifies the box of kittens"
@@ -225,34 +329,10 @@ func (c *Client) CreateDelivery(manifest *Manifest, pickup, dropoff *DeliverySpo
 	// dropoff_notes="Optional note to ring the bell"
 	// quote_id=qUdje83jhdk
 
-	resp, err := c.client.PostForm(
-		"/v1/customers/"+url.QueryEscape(c.customer_id)+"/deliveries",
-		url.Values{
-			"manifest":              []string{manifest.Description},
-			"manifest_reference":    []string{manifest.Reference},
-			"pickup_name":           []string{pickup.Name},
-			"pickup_address":        []string{pickup.Address},
-			"pickup_phone_number":   []string{pickup.PhoneNumber},
-			"pickup_business_name":  []string{pickup.BusinessName},
-			"pickup_notes":          []string{pickup.Notes},
-			"dropoff_name":          []string{dropoff.Name},
-			"dropoff_address":       []string{dropoff.Address},
-			"dropoff_phone_number":  []string{dropoff.PhoneNumber},
-			"dropoff_business_name": []string{dropoff.BusinessName},
-			"dropoff_notes":         []string{dropoff.Notes},
-			"quote_id":              []string{quote.ID},
-		},
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	dr := NewDeliveryRequest(manifest, pickup, dropoff)
+	dr.Quote = quote
 
-	if resp.StatusCode != http.StatusOK {
-		return NewError(resp)
-	}
-
-	return nil
+	return c.PlaceDeliveryRequestContext(ctx, dr)
 
 }
 
@@ -260,73 +340,65 @@ func (c *Client) GetOngoingDeliveries(n int) ([]*Delivery, error) {
 	return c.GetDeliveries(OngoingFilter, n)
 }
 
-func (c *Client) GetDeliveries(filter string, n int) ([]*Delivery, error) {
-
-	// GET /v1/customers/:customer_id/deliveries
-	// This endpoint currently supports one query argument:
-	// ?filter=ongoing
-
-	var (
-		da []*Delivery
-		u  = "/v1/customers/" + url.QueryEscape(c.customer_id) + "/deliveries?filter=" + url.QueryEscape(filter)
-	)
-
-	for {
-		resp, err := c.client.Get(u)
-		if err != nil {
-			return nil, err
-		}
+func (c *Client) GetOngoingDeliveriesContext(ctx context.Context, n int) ([]*Delivery, error) {
+	return c.GetDeliveriesContext(ctx, OngoingFilter, n)
+}
 
-		if resp.StatusCode != http.StatusOK {
-			defer resp.Body.Close()
-			return nil, NewError(resp)
-		}
+func (c *Client) GetDeliveries(filter string, n int) ([]*Delivery, error) {
+	return c.GetDeliveriesContext(context.Background(), filter, n)
+}
 
-		ds := &Deliveries{}
-		if err := json.NewDecoder(resp.Body).Decode(ds); err != nil {
-			resp.Body.Close()
-			return nil, err
-		}
+func (c *Client) GetDeliveriesContext(ctx context.Context, filter string, n int) ([]*Delivery, error) {
+	return c.ListDeliveries(ctx, &ListOptions{Filter: filter}, n)
+}
 
-		// no defer since we're in a loop
-		resp.Body.Close()
+func (c *Client) GetDelivery(delivery_id string) (*Delivery, error) {
+	return c.GetDeliveryContext(context.Background(), delivery_id)
+}
 
-		da = append(da, ds.Data...)
+func (c *Client) GetDeliveryContext(ctx context.Context, delivery_id string) (*Delivery, error) {
 
-		// if n == AllDeliveries we go till the wheels fall off
-		// sanity check ds.Data length
-		if (n != AllDeliveries && len(da) >= n) || len(ds.NextHref) == 0 || len(ds.Data) == 0 {
-			break
-		}
+	// GET /v1/customers/:customer_id/deliveries/:delivery_id
+	// Returns: Delivery Object
 
-		u = ds.NextHref
+	d := &Delivery{}
+	if err := c.do(ctx, readCall, "GET", c.customerPath("deliveries", delivery_id), nil, d); err != nil {
+		return nil, err
 	}
 
-	if n != AllDeliveries && len(da) > n {
-		da = da[:n]
-	}
+	return d, nil
 
-	return da, nil
+}
 
+func (c *Client) CancelDelivery(delivery_id string) (*Delivery, error) {
+	return c.CancelDeliveryContext(context.Background(), delivery_id)
 }
 
-func (c *Client) GetDelivery(delivery_id string) (*Delivery, error) {
+func (c *Client) CancelDeliveryContext(ctx context.Context, delivery_id string) (*Delivery, error) {
 
-	// GET /v1/customers/:customer_id/deliveries/:delivery_id
+	// POST /v1/customers/:customer_id/deliveries/:delivery_id/cancel
 	// Returns: Delivery Object
 
-	resp, err := c.client.Get("/v1/customers/" + url.QueryEscape(c.customer_id) + "/deliveries/" + url.QueryEscape(delivery_id))
-	if err != nil {
+	d := &Delivery{}
+	if err := c.do(ctx, writeCall, "POST", c.customerPath("deliveries", delivery_id, "cancel"), nil, d); err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, NewError(resp)
-	}
+	return d, nil
+
+}
+
+func (c *Client) ReturnDelivery(delivery_id string) (*Delivery, error) {
+	return c.ReturnDeliveryContext(context.Background(), delivery_id)
+}
+
+func (c *Client) ReturnDeliveryContext(ctx context.Context, delivery_id string) (*Delivery, error) {
+
+	// POST /v1/customers/:customer_id/deliveries/:delivery_id/return
+	// Returns: Delivery Object (the new return delivery)
 
 	d := &Delivery{}
-	if err := json.NewDecoder(resp.Body).Decode(d); err != nil {
+	if err := c.do(ctx, writeCall, "POST", c.customerPath("deliveries", delivery_id, "return"), nil, d); err != nil {
 		return nil, err
 	}
 
@@ -334,50 +406,136 @@ func (c *Client) GetDelivery(delivery_id string) (*Delivery, error) {
 
 }
 
-func (c *Client) CancelDelivery(delivery_id string) (*Delivery, error) {
+// form builds request bodies, leaving out unset fields
+type form url.Values
 
-	// POST /v1/customers/:customer_id/deliveries/:delivery_id/cancel
-	// Returns: Delivery Object
+func (f form) set(key, value string) {
+	if len(value) > 0 {
+		url.Values(f).Set(key, value)
+	}
+}
 
-	resp, err := c.client.PostForm("/v1/customers/"+url.QueryEscape(c.customer_id)+"/deliveries/"+url.QueryEscape(delivery_id)+"/cancel", nil)
-	if err != nil {
-		return nil, err
+func (f form) setBool(key string, value bool) {
+	if value {
+		url.Values(f).Set(key, "true")
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, NewError(resp)
+func (f form) setTime(key string, t *time.Time) {
+	if t != nil && !t.IsZero() {
+		url.Values(f).Set(key, t.Format(time.RFC3339))
 	}
+}
 
-	d := &Delivery{}
-	if err := json.NewDecoder(resp.Body).Decode(d); err != nil {
-		return nil, err
+func (f form) setLocation(prefix string, l *Location) {
+	if l != nil {
+		url.Values(f).Set(prefix+"_latitude", strconv.FormatFloat(l.Lat, 'f', -1, 64))
+		url.Values(f).Set(prefix+"_longitude", strconv.FormatFloat(l.Lng, 'f', -1, 64))
 	}
+}
 
-	return d, nil
+// customerPath builds an escaped path under /v1/customers/:customer_id
+func (c *Client) customerPath(elem ...string) string {
+	p := "/v1/customers/" + url.QueryEscape(c.customer_id)
+	for _, e := range elem {
+		p += "/" + url.QueryEscape(e)
+	}
+	return p
+}
+
+// do sends an api request bound to ctx, retrying per the client's RetryPolicy,
+// and decodes a 200 response body into v.  POST requests are form encoded.
+// A nil v discards the body.
+func (c *Client) do(ctx context.Context, kind callKind, method, u string, form url.Values, v interface{}) error {
+
+	if err := c.modeMismatch(); err != nil {
+		return err
+	}
+
+	// learn the account's mode before anything is created in it
+	if kind == writeCall {
+		if err := c.verifyMode(ctx); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+
+		// every attempt, retries included, spends from the budget
+		if err := c.limiter(kind).Wait(ctx); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		err := c.send(ctx, method, u, form, v)
+		if err == nil {
+			err = c.checkMode(v)
+		}
+		c.log.call(ctx, method, u, form, attempt, time.Since(start), err)
+
+		if err == nil || attempt >= c.retry.MaxAttempts || !c.retry.retryable(kind, err) {
+			return err
+		}
+
+		var (
+			retry_after time.Duration
+			e           *Error
+		)
+		if errors.As(err, &e) {
+			retry_after = e.RetryAfter
+		}
+
+		delay, ok := c.retry.backoff(attempt, retry_after)
+		if !ok {
+			return err
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+
+	}
 
 }
 
-func (c *Client) ReturnDelivery(delivery_id string) (*Delivery, error) {
+// send makes a single request attempt
+func (c *Client) send(ctx context.Context, method, u string, form url.Values, v interface{}) error {
 
-	// POST /v1/customers/:customer_id/deliveries/:delivery_id/return
-	// Returns: Delivery Object (the new return delivery)
+	var body io.Reader
+	if method == "POST" {
+		body = strings.NewReader(form.Encode())
+	}
 
-	resp, err := c.client.PostForm("/v1/customers/"+url.QueryEscape(c.customer_id)+"/deliveries/"+url.QueryEscape(delivery_id)+"/return", nil)
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &TransportError{Err: err}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, NewError(resp)
+		return NewError(resp)
 	}
 
-	d := &Delivery{}
-	if err := json.NewDecoder(resp.Body).Decode(d); err != nil {
-		return nil, err
+	if v == nil {
+		return nil
 	}
 
-	return d, nil
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return &DecodeError{Err: err}
+	}
+
+	return nil
 
 }
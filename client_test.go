@@ -1,8 +1,13 @@
 package ghostmates
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
@@ -54,6 +59,21 @@ func init() {
 	}
 }
 
+// newTestClient returns a client pointed at a local server running handler
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+
+	s := httptest.NewServer(handler)
+	t.Cleanup(s.Close)
+
+	client, err := NewClientWithOptions(TestCustomerId, TestAPIKey, append([]Option{WithBaseURL(s.URL), WithTimeout(TestTimeout)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+
+}
+
 func TestGetQuote(t *testing.T) {
 
 	client := NewClient(TestCustomerId, TestAPIKey, TestTimeout)
@@ -105,6 +125,28 @@ func TestClientTimeout(t *testing.T) {
 
 }
 
+func TestClientContextCanceled(t *testing.T) {
+
+	client := NewClient(TestCustomerId, TestAPIKey, TestTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.GetQuoteContext(ctx, TestPickupAddress, TestDropoffAddress)
+	if err == nil {
+		t.Fatalf("Expected a canceled error, got nil")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %q", err.Error())
+	}
+
+	_, err = client.GetDeliveriesContext(ctx, AllFilter, AllDeliveries)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+}
+
 func TestCreateDelivery(t *testing.T) {
 
 	client := NewClient(TestCustomerId, TestAPIKey, TestTimeout)
@@ -131,6 +173,105 @@ func TestCreateDelivery(t *testing.T) {
 
 }
 
+func TestPlaceDelivery(t *testing.T) {
+
+	client := NewClient(TestCustomerId, TestAPIKey, TestTimeout)
+
+	quote, err := client.GetQuote(TestPickupAddress, TestDropoffAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := NewManifest(TestManifestDescription, TestManifestReference)
+
+	pickup := NewDeliverySpot(TestPickupName, TestPickupAddress, TestPickupPhoneNumber)
+	pickup.BusinessName = TestPickupOptionalBusinessName
+	pickup.Notes = TestPickupOptionalNotes
+
+	dropoff := NewDeliverySpot(TestDropoffName, TestDropoffAddress, TestDropoffPhoneNumber)
+	dropoff.BusinessName = TestDropoffOptionalBusinessName
+	dropoff.Notes = TestDropoffOptionalNotes
+
+	d, err := client.PlaceDelivery(manifest, pickup, dropoff, quote)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Kind != DeliveryKind {
+		t.Errorf("Expected %q, got %q", DeliveryKind, d.Kind)
+	}
+	if len(d.ID) == 0 {
+		t.Errorf("Expected an ID")
+	}
+	if d.QuoteID != quote.ID {
+		t.Errorf("Expected %q, got %q", quote.ID, d.QuoteID)
+	}
+
+}
+
+func TestPlaceDeliveryResponse(t *testing.T) {
+
+	var form url.Values
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		form = req.PostForm
+		w.Write([]byte(`{"kind":"delivery","id":"del_test","status":"pending","fee":799,"currency":"usd","quote_id":"dqt_test","dropoff_eta":"2015-05-24T19:48:57Z"}`))
+	})
+
+	var (
+		manifest = NewManifest(TestManifestDescription, TestManifestReference)
+		pickup   = NewDeliverySpot(TestPickupName, TestPickupAddress, TestPickupPhoneNumber)
+		dropoff  = NewDeliverySpot(TestDropoffName, TestDropoffAddress, TestDropoffPhoneNumber)
+		quote    = &DeliveryQuote{ID: "dqt_test"}
+	)
+
+	d, err := client.PlaceDelivery(manifest, pickup, dropoff, quote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ID != "del_test" || d.Status != StatusPending || d.Fee != 799 || d.DropoffEta == nil {
+		t.Errorf("Unexpected delivery %+v", d)
+	}
+	if form.Get("quote_id") != "dqt_test" || form.Get("dropoff_address") != TestDropoffAddress {
+		t.Errorf("Unexpected form %v", form)
+	}
+
+	// the deprecated form still works, minus the delivery
+	if err := client.CreateDelivery(manifest, pickup, dropoff, quote); err != nil {
+		t.Error(err)
+	}
+
+}
+
+func TestLegacyRequests(t *testing.T) {
+
+	var form url.Values
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		form = req.PostForm
+		w.Write([]byte(`{"kind":"delivery","id":"del_test"}`))
+	})
+
+	// sent as given, empty fields included and unchecked
+	err := client.CreateDelivery(NewManifest("", ""), NewDeliverySpot("", TestPickupAddress, ""), NewDeliverySpot("", TestDropoffAddress, ""), &DeliveryQuote{ID: "dqt_test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(form) != 13 || form.Get("quote_id") != "dqt_test" || !form.Has("manifest") || !form.Has("pickup_notes") {
+		t.Errorf("Expected all 13 fields, got %v", form)
+	}
+
+	if _, err := client.GetQuote("", TestDropoffAddress); err != nil {
+		t.Fatal(err)
+	}
+	if len(form) != 2 || !form.Has("pickup_address") {
+		t.Errorf("Expected both addresses, got %v", form)
+	}
+
+}
+
 func TestGetDeliveries(t *testing.T) {
 
 	client := NewClient(TestCustomerId, TestAPIKey, TestTimeout)
@@ -0,0 +1,136 @@
+package ghostmates
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// CredentialsProvider supplies the api key for each request, letting
+	// keys be rotated without recreating the client.
+	CredentialsProvider interface {
+		APIKey(ctx context.Context) (string, error)
+	}
+
+	// CredentialsRefresher is implemented by providers that can reload their
+	// key on demand.  When the api rejects a key with a 401 the client calls
+	// Refresh and, if the key changed, retries the request once.
+	CredentialsRefresher interface {
+		Refresh(ctx context.Context) error
+	}
+
+	staticCredentials string
+
+	envCredentials string
+
+	// FileCredentials reads the api key from a file, rereading it when its
+	// modification time changes.  The file is checked at most once per
+	// interval.  Refresh always rereads it, since the key was just rejected
+	// and a rewrite may not have moved the modification time.
+	FileCredentials struct {
+		path     string
+		interval time.Duration
+
+		mu      sync.Mutex
+		key     string
+		mod     time.Time
+		checked time.Time
+	}
+)
+
+// StaticCredentials always returns api_key.
+func StaticCredentials(api_key string) CredentialsProvider {
+	return staticCredentials(api_key)
+}
+
+func (sc staticCredentials) APIKey(ctx context.Context) (string, error) {
+	return string(sc), nil
+}
+
+// EnvCredentials reads the api key from the environment variable name on
+// every request.
+func EnvCredentials(name string) CredentialsProvider {
+	return envCredentials(name)
+}
+
+func (ec envCredentials) APIKey(ctx context.Context) (string, error) {
+	key := strings.TrimSpace(os.Getenv(string(ec)))
+	if len(key) == 0 {
+		return "", fmt.Errorf("environment variable %s is not set", string(ec))
+	}
+	return key, nil
+}
+
+// NewFileCredentials returns a provider reading the api key from path,
+// checking it for changes at most once per interval.
+func NewFileCredentials(path string, interval time.Duration) *FileCredentials {
+	return &FileCredentials{path: path, interval: interval}
+}
+
+func (fc *FileCredentials) APIKey(ctx context.Context) (string, error) {
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	if len(fc.key) > 0 && time.Since(fc.checked) < fc.interval {
+		return fc.key, nil
+	}
+
+	if err := fc.load(false); err != nil {
+		return "", err
+	}
+
+	return fc.key, nil
+
+}
+
+func (fc *FileCredentials) Refresh(ctx context.Context) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.load(true)
+}
+
+// load rereads the key if the file's modification time changed, or always
+// when forced.  fc.mu must be held.
+func (fc *FileCredentials) load(force bool) error {
+
+	fi, err := os.Stat(fc.path)
+	if err != nil {
+		return err
+	}
+	fc.checked = time.Now()
+
+	if !force && len(fc.key) > 0 && fi.ModTime().Equal(fc.mod) {
+		return nil
+	}
+
+	data, err := os.ReadFile(fc.path)
+	if err != nil {
+		return err
+	}
+	key := strings.TrimSpace(string(data))
+	if len(key) == 0 {
+		return fmt.Errorf("no api key in %s", fc.path)
+	}
+
+	fc.key, fc.mod = key, fi.ModTime()
+
+	return nil
+
+}
+
+// WithCredentials authenticates requests with the key from p instead of
+// the api key the client was created with.
+func WithCredentials(p CredentialsProvider) Option {
+	return func(c *Client) error {
+		if p == nil {
+			return fmt.Errorf("nil credentials provider")
+		}
+		c.credentials = p
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCredentialsRotation(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "postmates_key")
+	if err := os.WriteFile(path, []byte("key_old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		key, _, _ := req.BasicAuth()
+		keys = append(keys, key+" "+req.FormValue("dropoff_address"))
+		if key != "key_new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"kind":"delivery_quote","id":"dqt_test"}`))
+	}, WithCredentials(NewFileCredentials(path, time.Hour)))
+
+	// the key hasn't changed, so there's nothing to retry with
+	var e *Error
+	if _, err := client.GetQuote(TestPickupAddress, TestDropoffAddress); !errors.As(err, &e) || e.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Expected a 401, got %v", err)
+	}
+
+	// rotate the key, the provider won't notice until the api rejects the
+	// old one.  The rewrite keeps the old modification time, as cp -p would.
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("key_new\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, fi.ModTime(), fi.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+
+	dq, err := client.GetQuote(TestPickupAddress, TestDropoffAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dq.ID != "dqt_test" {
+		t.Errorf("Unexpected quote %+v", dq)
+	}
+
+	// the retried request carries the same body
+	expected := fmt.Sprint([]string{
+		"key_old " + TestDropoffAddress,
+		"key_old " + TestDropoffAddress,
+		"key_new " + TestDropoffAddress,
+	})
+	if fmt.Sprint(keys) != expected {
+		t.Errorf("Expected %s, got %s", expected, keys)
+	}
+
+}
+
+func TestEnvCredentials(t *testing.T) {
+
+	const name = "GHOSTMATES_TEST_API_KEY"
+
+	t.Setenv(name, "")
+	if _, err := EnvCredentials(name).APIKey(context.Background()); err == nil {
+		t.Errorf("Expected an error for an unset key")
+	}
+
+	t.Setenv(name, " key_env ")
+	if key, err := EnvCredentials(name).APIKey(context.Background()); err != nil || key != "key_env" {
+		t.Errorf("Expected key_env, got %q %v", key, err)
+	}
+
+	var auth string
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		auth, _, _ = req.BasicAuth()
+		w.Write([]byte(`{"kind":"delivery","id":"del_test"}`))
+	}, WithCredentials(EnvCredentials(name)))
+
+	t.Setenv(name, "key_rotated")
+	if _, err := client.GetDelivery("del_test"); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "key_rotated" {
+		t.Errorf("Expected the current environment key, got %q", auth)
+	}
+
+}
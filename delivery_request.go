@@ -0,0 +1,299 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type (
+	// DeliveryRequest holds the full set of create delivery parameters.
+	// Only set fields are sent.
+	DeliveryRequest struct {
+		Manifest Manifest
+		Pickup   DeliverySpot
+		Dropoff  DeliverySpot
+
+		PickupLocation  *Location // Lat/lng hint used to disambiguate the pickup address
+		DropoffLocation *Location // Lat/lng hint used to disambiguate the dropoff address
+
+		PickupReady     *time.Time // Earliest time the items will be ready for pickup
+		PickupDeadline  *time.Time // Latest time the items may be picked up
+		DropoffReady    *time.Time // Earliest time the items may be dropped off
+		DropoffDeadline *time.Time // Latest time the items may be dropped off
+
+		RequiresDropoffSignature bool   // Courier must collect a signature at dropoff
+		RequiresID               bool   // Courier must verify the recipient's identification at dropoff
+		UndeliverableAction      string // What the courier does when dropoff fails, one of the Undeliverable* constants
+		Tip                      int    // Amount in cents tipped to the courier
+
+		Quote   *DeliveryQuote    // Optional quote to lock in the quoted fee
+		Requote *RequoteTolerance // Opt in to replacing an expired Quote with a fresh one within these limits
+	}
+
+	// RequoteTolerance bounds how much worse a fresh quote may be than the
+	// expired one it replaces.
+	RequoteTolerance struct {
+		MaxFeeIncrease  int           // Amount in cents the fee may go up by
+		MaxDropoffDelay time.Duration // How much later the dropoff eta may be
+	}
+
+	// RequoteError is returned when the fresh quote for an expired one falls
+	// outside of the caller's RequoteTolerance.  No delivery is created.
+	RequoteError struct {
+		Expired *DeliveryQuote
+		Fresh   *DeliveryQuote
+		Reason  string
+	}
+)
+
+var (
+	ErrQuoteExpired = errors.New("delivery quote expired")
+)
+
+const (
+	UndeliverableLeaveAtDoor = "leave_at_door" // Leave the items at the door
+	UndeliverableReturn      = "return"        // Return the items to the pickup location
+)
+
+// NewDeliveryRequest copies the manifest and spots into a request, set the
+// remaining fields on it before placing it.
+func NewDeliveryRequest(manifest *Manifest, pickup, dropoff *DeliverySpot) *DeliveryRequest {
+	return &DeliveryRequest{
+		Manifest: *manifest,
+		Pickup:   *pickup,
+		Dropoff:  *dropoff,
+	}
+}
+
+// Validate checks the request the way the api would, returning a
+// *ValidationError naming the first offending field.
+func (dr *DeliveryRequest) Validate() error {
+
+	if len(dr.Manifest.Description) == 0 && len(dr.Manifest.Items) == 0 {
+		return &ValidationError{Field: "Manifest.Description", Message: "is required without Manifest.Items"}
+	}
+	if err := dr.Manifest.validateItems(); err != nil {
+		return err
+	}
+
+	for _, f := range []struct{ name, value string }{
+		{"Pickup.Name", dr.Pickup.Name},
+		{"Pickup.Address", dr.Pickup.Address},
+		{"Pickup.PhoneNumber", dr.Pickup.PhoneNumber},
+		{"Dropoff.Name", dr.Dropoff.Name},
+		{"Dropoff.Address", dr.Dropoff.Address},
+		{"Dropoff.PhoneNumber", dr.Dropoff.PhoneNumber},
+	} {
+		if len(f.value) == 0 {
+			return &ValidationError{Field: f.name, Message: "is required"}
+		}
+	}
+
+	if err := validateLocation("PickupLocation", dr.PickupLocation); err != nil {
+		return err
+	}
+	if err := validateLocation("DropoffLocation", dr.DropoffLocation); err != nil {
+		return err
+	}
+
+	if err := validateWindow("PickupDeadline", dr.PickupReady, dr.PickupDeadline); err != nil {
+		return err
+	}
+	if err := validateWindow("DropoffDeadline", dr.DropoffReady, dr.DropoffDeadline); err != nil {
+		return err
+	}
+	if err := validateWindow("DropoffDeadline", dr.PickupReady, dr.DropoffDeadline); err != nil {
+		return err
+	}
+
+	switch dr.UndeliverableAction {
+	case "", UndeliverableLeaveAtDoor, UndeliverableReturn:
+	default:
+		return &ValidationError{Field: "UndeliverableAction", Message: "must be " + UndeliverableLeaveAtDoor + " or " + UndeliverableReturn}
+	}
+
+	if dr.Tip < 0 {
+		return &ValidationError{Field: "Tip", Message: "must not be negative"}
+	}
+
+	return nil
+
+}
+
+// Values form encodes the set fields of the request, with manifest items
+// folded into the manifest text.
+func (dr *DeliveryRequest) Values() url.Values {
+	return dr.values(false)
+}
+
+// ValuesWithManifestItems form encodes the set fields of the request, with
+// manifest items sent as manifest_items.  See WithManifestItems.
+func (dr *DeliveryRequest) ValuesWithManifestItems() url.Values {
+	return dr.values(true)
+}
+
+func (dr *DeliveryRequest) values(items bool) url.Values {
+
+	v := form{}
+
+	v.setManifest(&dr.Manifest, items)
+
+	v.set("pickup_name", dr.Pickup.Name)
+	v.set("pickup_address", dr.Pickup.Address)
+	v.set("pickup_phone_number", dr.Pickup.PhoneNumber)
+	v.set("pickup_business_name", dr.Pickup.BusinessName)
+	v.set("pickup_notes", dr.Pickup.Notes)
+	v.setLocation("pickup", dr.PickupLocation)
+	v.setTime("pickup_ready_dt", dr.PickupReady)
+	v.setTime("pickup_deadline_dt", dr.PickupDeadline)
+
+	v.set("dropoff_name", dr.Dropoff.Name)
+	v.set("dropoff_address", dr.Dropoff.Address)
+	v.set("dropoff_phone_number", dr.Dropoff.PhoneNumber)
+	v.set("dropoff_business_name", dr.Dropoff.BusinessName)
+	v.set("dropoff_notes", dr.Dropoff.Notes)
+	v.setLocation("dropoff", dr.DropoffLocation)
+	v.setTime("dropoff_ready_dt", dr.DropoffReady)
+	v.setTime("dropoff_deadline_dt", dr.DropoffDeadline)
+
+	v.setBool("requires_dropoff_signature", dr.RequiresDropoffSignature)
+	v.setBool("requires_id", dr.RequiresID)
+	v.set("undeliverable_action", dr.UndeliverableAction)
+	if dr.Tip > 0 {
+		v.set("tip_by_customer", strconv.Itoa(dr.Tip))
+	}
+
+	if dr.Quote != nil {
+		v.set("quote_id", dr.Quote.ID)
+	}
+
+	return url.Values(v)
+
+}
+
+// PlaceDeliveryRequest validates dr locally and creates the delivery it describes.
+func (c *Client) PlaceDeliveryRequest(dr *DeliveryRequest) (*Delivery, error) {
+	return c.PlaceDeliveryRequestContext(context.Background(), dr)
+}
+
+func (c *Client) PlaceDeliveryRequestContext(ctx context.Context, dr *DeliveryRequest) (*Delivery, error) {
+
+	// POST /v1/customers/:customer_id/deliveries
+	// Returns: Delivery Object
+
+	if err := dr.Validate(); err != nil {
+		return nil, err
+	}
+
+	if dr.Quote.Expired(c.now()) {
+		fresh, err := c.requote(ctx, dr)
+		if err != nil {
+			return nil, err
+		}
+		// leave the caller's request as it was
+		requoted := *dr
+		requoted.Quote = fresh
+		dr = &requoted
+	}
+
+	d := &Delivery{}
+	if err := c.do(ctx, writeCall, "POST", c.customerPath("deliveries"), dr.values(c.manifest_items), d); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+
+}
+
+// QuoteRequest returns a request for quoting this delivery.
+func (dr *DeliveryRequest) QuoteRequest() *QuoteRequest {
+	return &QuoteRequest{
+		PickupAddress:      dr.Pickup.Address,
+		DropoffAddress:     dr.Dropoff.Address,
+		PickupLocation:     dr.PickupLocation,
+		DropoffLocation:    dr.DropoffLocation,
+		PickupPhoneNumber:  dr.Pickup.PhoneNumber,
+		DropoffPhoneNumber: dr.Dropoff.PhoneNumber,
+		PickupReady:        dr.PickupReady,
+		PickupDeadline:     dr.PickupDeadline,
+		DropoffReady:       dr.DropoffReady,
+		DropoffDeadline:    dr.DropoffDeadline,
+	}
+}
+
+// requote replaces the expired quote on dr if dr opted in and the fresh
+// quote is within tolerance
+func (c *Client) requote(ctx context.Context, dr *DeliveryRequest) (*DeliveryQuote, error) {
+
+	if dr.Requote == nil {
+		return nil, fmt.Errorf("%w: %s expired at %s", ErrQuoteExpired, dr.Quote.ID, dr.Quote.Expires.Format(time.RFC3339))
+	}
+
+	fresh, err := c.GetQuoteRequestContext(ctx, dr.QuoteRequest())
+	if err != nil {
+		// the caller sent a DeliveryRequest, not the QuoteRequest built from it
+		return nil, withParamFields(err, paramFields)
+	}
+
+	if reason := dr.Requote.check(dr.Quote, fresh); len(reason) > 0 {
+		return nil, &RequoteError{Expired: dr.Quote, Fresh: fresh, Reason: reason}
+	}
+
+	return fresh, nil
+
+}
+
+// check returns why fresh is out of tolerance, or nothing
+func (rt *RequoteTolerance) check(expired, fresh *DeliveryQuote) string {
+
+	if len(expired.Currency) > 0 && len(fresh.Currency) > 0 && expired.Currency != fresh.Currency {
+		return fmt.Sprintf("currency changed from %s to %s", expired.Currency, fresh.Currency)
+	}
+
+	if increase := fresh.Fee - expired.Fee; increase > rt.MaxFeeIncrease {
+		return fmt.Sprintf("fee increased by %d, more than %d", increase, rt.MaxFeeIncrease)
+	}
+
+	if expired.DropoffEta != nil && fresh.DropoffEta != nil {
+		if delay := fresh.DropoffEta.Sub(*expired.DropoffEta); delay > rt.MaxDropoffDelay {
+			return fmt.Sprintf("dropoff eta delayed by %s, more than %s", delay, rt.MaxDropoffDelay)
+		}
+	}
+
+	return ""
+
+}
+
+func (e *RequoteError) Error() string {
+	return fmt.Sprintf("requote for expired delivery quote %s rejected: %s", e.Expired.ID, e.Reason)
+}
+
+// Unwrap lets errors.Is(err, ErrQuoteExpired) match a rejected requote
+func (e *RequoteError) Unwrap() error {
+	return ErrQuoteExpired
+}
+
+func validateLocation(field string, l *Location) error {
+	if l == nil {
+		return nil
+	}
+	if l.Lat < -90 || l.Lat > 90 {
+		return &ValidationError{Field: field + ".Lat", Message: "must be between -90 and 90"}
+	}
+	if l.Lng < -180 || l.Lng > 180 {
+		return &ValidationError{Field: field + ".Lng", Message: "must be between -180 and 180"}
+	}
+	return nil
+}
+
+// validateWindow reports field when the window closes before it opens
+func validateWindow(field string, open, close *time.Time) error {
+	if open != nil && close != nil && close.Before(*open) {
+		return &ValidationError{Field: field, Message: "must not be before the window opens"}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestDeliveryRequest() *DeliveryRequest {
+	return NewDeliveryRequest(
+		NewManifest(TestManifestDescription, TestManifestReference),
+		NewDeliverySpot(TestPickupName, TestPickupAddress, TestPickupPhoneNumber),
+		NewDeliverySpot(TestDropoffName, TestDropoffAddress, TestDropoffPhoneNumber),
+	)
+}
+
+func TestDeliveryRequestValues(t *testing.T) {
+
+	dr := newTestDeliveryRequest()
+
+	// only set fields are encoded
+	expected := url.Values{
+		"manifest":             []string{TestManifestDescription},
+		"manifest_reference":   []string{TestManifestReference},
+		"pickup_name":          []string{TestPickupName},
+		"pickup_address":       []string{TestPickupAddress},
+		"pickup_phone_number":  []string{TestPickupPhoneNumber},
+		"dropoff_name":         []string{TestDropoffName},
+		"dropoff_address":      []string{TestDropoffAddress},
+		"dropoff_phone_number": []string{TestDropoffPhoneNumber},
+	}
+	if v := dr.Values(); !reflect.DeepEqual(v, expected) {
+		t.Errorf("Expected %v, got %v", expected, v)
+	}
+
+	ready := time.Date(2015, 5, 24, 19, 0, 0, 0, time.UTC)
+	deadline := ready.Add(time.Hour)
+
+	dr.PickupLocation = &Location{Lat: TestPickupLat, Lng: TestPickupLng}
+	dr.PickupReady = &ready
+	dr.DropoffDeadline = &deadline
+	dr.RequiresDropoffSignature = true
+	dr.UndeliverableAction = UndeliverableReturn
+	dr.Tip = 500
+	dr.Quote = &DeliveryQuote{ID: "dqt_test"}
+
+	v := dr.Values()
+	for key, value := range map[string]string{
+		"pickup_latitude":            "40.74527",
+		"pickup_longitude":           "-74.007889",
+		"pickup_ready_dt":            "2015-05-24T19:00:00Z",
+		"dropoff_deadline_dt":        "2015-05-24T20:00:00Z",
+		"requires_dropoff_signature": "true",
+		"undeliverable_action":       "return",
+		"tip_by_customer":            "500",
+		"quote_id":                   "dqt_test",
+	} {
+		if v.Get(key) != value {
+			t.Errorf("%s: expected %q, got %q", key, value, v.Get(key))
+		}
+	}
+	for _, key := range []string{"requires_id", "dropoff_latitude", "pickup_deadline_dt"} {
+		if _, ok := v[key]; ok {
+			t.Errorf("Expected %s to be omitted", key)
+		}
+	}
+
+}
+
+func TestDeliveryRequestValidate(t *testing.T) {
+
+	if err := newTestDeliveryRequest().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		ready  = time.Now()
+		before = ready.Add(-time.Minute)
+	)
+
+	for field, mutate := range map[string]func(*DeliveryRequest){
+		"Manifest.Description": func(dr *DeliveryRequest) { dr.Manifest.Description = "" },
+		"Pickup.Address":       func(dr *DeliveryRequest) { dr.Pickup.Address = "" },
+		"Dropoff.PhoneNumber":  func(dr *DeliveryRequest) { dr.Dropoff.PhoneNumber = "" },
+		"DropoffLocation.Lat":  func(dr *DeliveryRequest) { dr.DropoffLocation = &Location{Lat: 91} },
+		"PickupDeadline":       func(dr *DeliveryRequest) { dr.PickupReady, dr.PickupDeadline = &ready, &before },
+		"UndeliverableAction":  func(dr *DeliveryRequest) { dr.UndeliverableAction = "shrug" },
+		"Tip":                  func(dr *DeliveryRequest) { dr.Tip = -1 },
+	} {
+		dr := newTestDeliveryRequest()
+		mutate(dr)
+		err := dr.Validate()
+		if e, ok := err.(*ValidationError); !ok || e.Field != field {
+			t.Errorf("Expected a validation error on %s, got %v", field, err)
+		}
+	}
+
+}
+
+func TestPlaceDeliveryRequestInvalid(t *testing.T) {
+
+	var requests int32
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	})
+
+	dr := newTestDeliveryRequest()
+	dr.Dropoff.Address = ""
+
+	if _, err := client.PlaceDeliveryRequestContext(context.Background(), dr); err == nil {
+		t.Errorf("Expected a validation error")
+	}
+	if requests != 0 {
+		t.Errorf("Expected no requests, got %d", requests)
+	}
+
+}
+
+func TestPlaceDeliveryRequestQuoteExpiry(t *testing.T) {
+
+	var (
+		now     = time.Date(2015, 5, 24, 19, 0, 0, 0, time.UTC)
+		expires = now.Add(-time.Minute)
+		eta     = now.Add(time.Hour)
+		quoted  int32
+		form    url.Values
+	)
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		if strings.HasSuffix(req.URL.Path, "/delivery_quotes") {
+			atomic.AddInt32(&quoted, 1)
+			w.Write([]byte(`{"kind":"delivery_quote","id":"dqt_fresh","fee":850,"currency":"usd","dropoff_eta":"2015-05-24T20:10:00Z"}`))
+			return
+		}
+		form = req.PostForm
+		w.Write([]byte(`{"kind":"delivery","id":"del_test","quote_id":"dqt_fresh"}`))
+	}, WithClock(func() time.Time { return now }))
+
+	dr := newTestDeliveryRequest()
+	dr.Quote = &DeliveryQuote{ID: "dqt_expired", Fee: 800, Currency: "usd", Expires: &expires, DropoffEta: &eta}
+
+	// expired quotes are refused locally unless requoting is opted into
+	if _, err := client.PlaceDeliveryRequestContext(context.Background(), dr); !errors.Is(err, ErrQuoteExpired) {
+		t.Errorf("Expected ErrQuoteExpired, got %v", err)
+	}
+	if err := client.CreateDelivery(&dr.Manifest, &dr.Pickup, &dr.Dropoff, dr.Quote); !errors.Is(err, ErrQuoteExpired) {
+		t.Errorf("Expected ErrQuoteExpired from CreateDelivery, got %v", err)
+	}
+	if quoted != 0 || form != nil {
+		t.Errorf("Expected no requests")
+	}
+
+	// fresh quote is 50 cents and 10 minutes worse
+	dr.Requote = &RequoteTolerance{MaxFeeIncrease: 25, MaxDropoffDelay: time.Hour}
+	_, err := client.PlaceDeliveryRequest(dr)
+	if e, ok := err.(*RequoteError); !ok || e.Fresh.ID != "dqt_fresh" {
+		t.Errorf("Expected a RequoteError, got %v", err)
+	}
+	if form != nil {
+		t.Errorf("Expected no delivery to be created")
+	}
+
+	dr.Requote = &RequoteTolerance{MaxFeeIncrease: 100, MaxDropoffDelay: 5 * time.Minute}
+	if _, err := client.PlaceDeliveryRequestContext(context.Background(), dr); err == nil {
+		t.Errorf("Expected a RequoteError for the dropoff delay")
+	}
+
+	dr.Requote = &RequoteTolerance{MaxFeeIncrease: 100, MaxDropoffDelay: time.Hour}
+	d, err := client.PlaceDeliveryRequestContext(context.Background(), dr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.QuoteID != "dqt_fresh" || form.Get("quote_id") != "dqt_fresh" {
+		t.Errorf("Expected the fresh quote to be used, sent %q", form.Get("quote_id"))
+	}
+	if dr.Quote.ID != "dqt_expired" {
+		t.Errorf("Expected the caller's request to be left alone")
+	}
+	if quoted != 3 {
+		t.Errorf("Expected 3 quotes, got %d", quoted)
+	}
+
+}
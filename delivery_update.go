@@ -0,0 +1,105 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+type (
+	// DeliveryUpdate holds the changes that can be made to a delivery after
+	// it is created.  Only set fields are sent.
+	DeliveryUpdate struct {
+		DropoffNotes       string // Replaces the dropoff notes
+		DropoffPhoneNumber string // Replaces the dropoff phone number
+		Tip                int    // Amount in cents tipped to the courier
+	}
+)
+
+var (
+	ErrUpdateNotAllowed = errors.New("delivery can no longer be updated")
+	ErrEmptyUpdate      = errors.New("delivery update has no changes")
+)
+
+// Validate checks the update locally, returning a *ValidationError naming
+// the first offending field, or ErrEmptyUpdate if nothing is set.
+func (du *DeliveryUpdate) Validate() error {
+
+	if du.Tip < 0 {
+		return &ValidationError{Field: "Tip", Message: "must not be negative"}
+	}
+
+	if len(du.DropoffNotes) == 0 && len(du.DropoffPhoneNumber) == 0 && du.Tip == 0 {
+		return ErrEmptyUpdate
+	}
+
+	return nil
+
+}
+
+// Values form encodes the set fields of the update.
+func (du *DeliveryUpdate) Values() url.Values {
+
+	v := form{}
+
+	v.set("dropoff_notes", du.DropoffNotes)
+	v.set("dropoff_phone_number", du.DropoffPhoneNumber)
+	if du.Tip > 0 {
+		v.set("tip_by_customer", strconv.Itoa(du.Tip))
+	}
+
+	return url.Values(v)
+
+}
+
+// allowed returns ErrUpdateNotAllowed if d has progressed past the point
+// where the update means anything.  Dropoff details are useless once the
+// items are delivered, but a tip can still be added afterwards.
+func (du *DeliveryUpdate) allowed(d *Delivery) error {
+
+	switch {
+	case d.Status == StatusDelivered:
+		if len(du.DropoffNotes) > 0 || len(du.DropoffPhoneNumber) > 0 {
+			return fmt.Errorf("%w: %s is already delivered, only a tip can be added", ErrUpdateNotAllowed, d.ID)
+		}
+	case d.Status.IsTerminal():
+		return fmt.Errorf("%w: %s is %s", ErrUpdateNotAllowed, d.ID, d.Status)
+	}
+
+	return nil
+
+}
+
+func (c *Client) UpdateDelivery(delivery_id string, du DeliveryUpdate) (*Delivery, error) {
+	return c.UpdateDeliveryContext(context.Background(), delivery_id, du)
+}
+
+// UpdateDeliveryContext fetches the delivery to check du still applies to
+// it, then applies du and returns the updated delivery.
+func (c *Client) UpdateDeliveryContext(ctx context.Context, delivery_id string, du DeliveryUpdate) (*Delivery, error) {
+
+	// POST /v1/customers/:customer_id/deliveries/:delivery_id
+	// Returns: Delivery Object
+
+	if err := du.Validate(); err != nil {
+		return nil, err
+	}
+
+	d, err := c.GetDeliveryContext(ctx, delivery_id)
+	if err != nil {
+		return nil, err
+	}
+	if err := du.allowed(d); err != nil {
+		return nil, err
+	}
+
+	d = &Delivery{}
+	if err := c.do(ctx, writeCall, "POST", c.customerPath("deliveries", delivery_id), du.Values(), d); err != nil {
+		return nil, withParamFields(err, updateParamFields)
+	}
+
+	return d, nil
+
+}
@@ -0,0 +1,71 @@
+package ghostmates
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestUpdateDelivery(t *testing.T) {
+
+	var (
+		status = StatusDropoff
+		form   url.Values
+		calls  []string
+	)
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		calls = append(calls, req.Method)
+		if req.Method == "POST" {
+			form = req.PostForm
+		}
+		fmt.Fprintf(w, `{"kind":"delivery","id":"del_test","status":%q,"dropoff":{"notes":%q}}`, status, req.PostForm.Get("dropoff_notes"))
+	})
+
+	d, err := client.UpdateDelivery("del_test", DeliveryUpdate{DropoffNotes: "Buzz 4B", Tip: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Dropoff.Notes != "Buzz 4B" {
+		t.Errorf("Expected the updated delivery, got %+v", d)
+	}
+	if fmt.Sprint(calls) != "[GET POST]" || form.Get("dropoff_notes") != "Buzz 4B" || form.Get("tip_by_customer") != "300" || form.Has("dropoff_phone_number") {
+		t.Errorf("Unexpected calls %v with form %v", calls, form)
+	}
+
+	// only tips once it's delivered, nothing once it's canceled
+	status = StatusDelivered
+	calls = nil
+	if _, err := client.UpdateDelivery("del_test", DeliveryUpdate{DropoffPhoneNumber: TestDropoffPhoneNumber}); !errors.Is(err, ErrUpdateNotAllowed) {
+		t.Errorf("Expected ErrUpdateNotAllowed, got %v", err)
+	}
+	if _, err := client.UpdateDelivery("del_test", DeliveryUpdate{Tip: 500}); err != nil {
+		t.Error(err)
+	}
+	status = StatusCanceled
+	if _, err := client.UpdateDelivery("del_test", DeliveryUpdate{Tip: 500}); !errors.Is(err, ErrUpdateNotAllowed) {
+		t.Errorf("Expected ErrUpdateNotAllowed, got %v", err)
+	}
+	if fmt.Sprint(calls) != "[GET GET POST GET]" {
+		t.Errorf("Expected refused updates not to be sent, got %v", calls)
+	}
+
+	calls = nil
+	var ve *ValidationError
+	if _, err := client.UpdateDelivery("del_test", DeliveryUpdate{Tip: -1}); !errors.As(err, &ve) || ve.Field != "Tip" {
+		t.Errorf("Expected a validation error on Tip, got %v", err)
+	}
+
+	// an empty update isn't any one field's fault
+	_, err = client.UpdateDelivery("del_test", DeliveryUpdate{})
+	if !errors.Is(err, ErrEmptyUpdate) || AsFieldErrors(err) != nil {
+		t.Errorf("Expected ErrEmptyUpdate without field errors, got %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected invalid updates not to be sent, got %v", calls)
+	}
+
+}
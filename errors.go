@@ -3,19 +3,54 @@ package ghostmates
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"time"
 )
 
 type (
 	Error struct {
-		StatusCode int    `json:"-"`
-		Status     string `json:"-"`
+		StatusCode         int           `json:"-"`
+		Status             string        `json:"-"`
+		RequestID          string        `json:"-"` // request id assigned by postmates, include it in support tickets
+		RetryAfter         time.Duration `json:"-"` // parsed Retry-After response header, zero if absent
+		RateLimitRemaining int           `json:"-"` // requests left in the current rate limit window, -1 if not reported
+		RateLimitReset     time.Time     `json:"-"` // when the current rate limit window resets, zero if not reported
+		Body               []byte        `json:"-"` // raw response body, truncated to MaxErrorBodyLength, when it is not a json error
 
 		Kind    string                 `json:"kind"`
 		Code    string                 `json:"code"`
 		Message string                 `json:"message"`
 		Params  map[string]interface{} `json:"params"`
+
+		fields map[string]string // api params to fields of the request that was sent, paramFields if nil
+	}
+
+	// TransportError wraps failures to reach the api or read its response,
+	// as opposed to an *Error where the api rejected the request.
+	TransportError struct {
+		Err error
+	}
+
+	// DecodeError wraps a failure to decode a successful api response.
+	DecodeError struct {
+		Err error
+	}
+
+	// FieldErrors maps go field paths of the request that was sent, eg.
+	// Dropoff.PhoneNumber for a DeliveryRequest, to the messages describing
+	// what is wrong with them.
+	FieldErrors map[string][]string
+
+	// ValidationError is returned when a request fails local checks before
+	// being sent.  Field is the go path of the offending field, eg. Dropoff.PhoneNumber.
+	ValidationError struct {
+		Field   string
+		Message string
 	}
 )
 
@@ -30,15 +65,120 @@ const (
 	ErrorCodeAddressUndeliverable     = "address_undeliverable"
 
 	ErrorKind = "error"
+
+	MaxErrorResponseLength = 64 << 10 // error responses are read up to 64kb, plenty for invalid_params on every field
+	MaxErrorBodyLength     = 4 << 10  // 4kb of an unparseable error body is kept
+
+	RequestIDHeader          = "X-Request-Id"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset" // unix seconds
+)
+
+var (
+	ErrInvalidParams            = errors.New("invalid params")
+	ErrUnknownLocation          = errors.New("unknown location")
+	ErrRequestRateLimitExceeded = errors.New("request rate limit exceeded")
+	ErrAccountSuspended         = errors.New("account suspended")
+	ErrNotFound                 = errors.New("not found")
+	ErrServiceUnavailable       = errors.New("service unavailable")
+	ErrDeliveryLimitExceeded    = errors.New("delivery limit exceeded")
+	ErrAddressUndeliverable     = errors.New("address undeliverable")
+
+	// api param names to the DeliveryRequest fields they are encoded from
+	paramFields = map[string]string{
+		"manifest":                   "Manifest.Description",
+		"manifest_reference":         "Manifest.Reference",
+		"manifest_items":             "Manifest.Items",
+		"pickup_name":                "Pickup.Name",
+		"pickup_address":             "Pickup.Address",
+		"pickup_phone_number":        "Pickup.PhoneNumber",
+		"pickup_business_name":       "Pickup.BusinessName",
+		"pickup_notes":               "Pickup.Notes",
+		"pickup_latitude":            "PickupLocation.Lat",
+		"pickup_longitude":           "PickupLocation.Lng",
+		"pickup_ready_dt":            "PickupReady",
+		"pickup_deadline_dt":         "PickupDeadline",
+		"dropoff_name":               "Dropoff.Name",
+		"dropoff_address":            "Dropoff.Address",
+		"dropoff_phone_number":       "Dropoff.PhoneNumber",
+		"dropoff_business_name":      "Dropoff.BusinessName",
+		"dropoff_notes":              "Dropoff.Notes",
+		"dropoff_latitude":           "DropoffLocation.Lat",
+		"dropoff_longitude":          "DropoffLocation.Lng",
+		"dropoff_ready_dt":           "DropoffReady",
+		"dropoff_deadline_dt":        "DropoffDeadline",
+		"requires_dropoff_signature": "RequiresDropoffSignature",
+		"requires_id":                "RequiresID",
+		"undeliverable_action":       "UndeliverableAction",
+		"tip_by_customer":            "Tip",
+		"quote_id":                   "Quote.ID",
+	}
+
+	// api param names to the QuoteRequest fields they are encoded from
+	quoteParamFields = map[string]string{
+		"pickup_address":       "PickupAddress",
+		"pickup_phone_number":  "PickupPhoneNumber",
+		"pickup_latitude":      "PickupLocation.Lat",
+		"pickup_longitude":     "PickupLocation.Lng",
+		"pickup_ready_dt":      "PickupReady",
+		"pickup_deadline_dt":   "PickupDeadline",
+		"dropoff_address":      "DropoffAddress",
+		"dropoff_phone_number": "DropoffPhoneNumber",
+		"dropoff_latitude":     "DropoffLocation.Lat",
+		"dropoff_longitude":    "DropoffLocation.Lng",
+		"dropoff_ready_dt":     "DropoffReady",
+		"dropoff_deadline_dt":  "DropoffDeadline",
+	}
+
+	// api param names to the DeliveryUpdate fields they are encoded from
+	updateParamFields = map[string]string{
+		"dropoff_notes":        "DropoffNotes",
+		"dropoff_phone_number": "DropoffPhoneNumber",
+		"tip_by_customer":      "Tip",
+	}
+
+	// errors.Is(err, ErrNotFound) matches an *Error with ErrorCodeNotFound etc.
+	errorCodes = map[string]error{
+		ErrorCodeInvalidParams:            ErrInvalidParams,
+		ErrorCodeUnknownLocation:          ErrUnknownLocation,
+		ErrorCodeRequestRateLimitExceeded: ErrRequestRateLimitExceeded,
+		ErrorCodeAccountSuspended:         ErrAccountSuspended,
+		ErrorCodeNotFound:                 ErrNotFound,
+		ErrorCodeServiceUnavailable:       ErrServiceUnavailable,
+		ErrorCodeDeliveryLimitExceeded:    ErrDeliveryLimitExceeded,
+		ErrorCodeAddressUndeliverable:     ErrAddressUndeliverable,
+	}
 )
 
 func NewError(resp *http.Response) *Error {
+
 	e := &Error{
-		StatusCode: resp.StatusCode,
-		Status:     resp.Status,
+		StatusCode:         resp.StatusCode,
+		Status:             resp.Status,
+		RequestID:          resp.Header.Get(RequestIDHeader),
+		RetryAfter:         parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		RateLimitRemaining: -1,
+	}
+
+	if n, err := strconv.Atoi(resp.Header.Get(RateLimitRemainingHeader)); err == nil {
+		e.RateLimitRemaining = n
+	}
+	if secs, err := strconv.ParseInt(resp.Header.Get(RateLimitResetHeader), 10, 64); err == nil {
+		e.RateLimitReset = time.Unix(secs, 0)
 	}
-	json.NewDecoder(resp.Body).Decode(e)
+
+	// errors from load balancers and proxies are often html, keep some of
+	// the body so there's something to go on
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, MaxErrorResponseLength))
+	if err := json.Unmarshal(data, e); err != nil || len(e.Kind) == 0 {
+		if len(data) > MaxErrorBodyLength {
+			data = data[:MaxErrorBodyLength]
+		}
+		e.Body = data
+	}
+
 	return e
+
 }
 
 func (e *Error) Error() string {
@@ -46,6 +186,133 @@ func (e *Error) Error() string {
 	fmt.Fprintf(buf, "Postmates API Error (%d %s)", e.StatusCode, e.Status)
 	if len(e.Kind) > 0 {
 		fmt.Fprintf(buf, " Kind: %s, Code: %s, Message: %s, Params: %v", e.Kind, e.Code, e.Message, e.Params)
+	} else if len(e.Body) > 0 {
+		fmt.Fprintf(buf, " Body: %q", e.Body)
 	}
 	return buf.String()
 }
+
+// Is matches the sentinel error for e.Code, eg. errors.Is(err, ErrNotFound).
+func (e *Error) Is(target error) bool {
+	sentinel, ok := errorCodes[e.Code]
+	return ok && sentinel == target
+}
+
+// FieldErrors decodes the per-field messages of an invalid_params error,
+// keyed by the field of the request type (DeliveryRequest, QuoteRequest or
+// DeliveryUpdate) each param came from.  Params without a matching field
+// keep their api name.
+func (e *Error) FieldErrors() FieldErrors {
+
+	fields := e.fields
+	if fields == nil {
+		fields = paramFields
+	}
+
+	fe := FieldErrors{}
+
+	for param, v := range e.Params {
+
+		field, ok := fields[param]
+		if !ok {
+			field = param
+		}
+
+		switch msg := v.(type) {
+		case string:
+			fe[field] = append(fe[field], msg)
+		case []interface{}:
+			for _, m := range msg {
+				fe[field] = append(fe[field], fmt.Sprint(m))
+			}
+		default:
+			fe[field] = append(fe[field], fmt.Sprint(msg))
+		}
+
+	}
+
+	return fe
+
+}
+
+// AsFieldErrors returns the field errors carried by err, whether the api
+// rejected the params or they failed local validation.  It returns nil if
+// err carries none.
+func AsFieldErrors(err error) FieldErrors {
+
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return FieldErrors{ve.Field: []string{ve.Message}}
+	}
+
+	var e *Error
+	if errors.As(err, &e) && len(e.Params) > 0 {
+		return e.FieldErrors()
+	}
+
+	return nil
+
+}
+
+// Fields returns the names of the fields with errors, sorted.
+func (fe FieldErrors) Fields() []string {
+	fields := make([]string, 0, len(fe))
+	for field := range fe {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// withParamFields names the fields of the request type that was sent in
+// any *Error in err
+func withParamFields(err error, fields map[string]string) error {
+	var e *Error
+	if errors.As(err, &e) {
+		e.fields = fields
+	}
+	return err
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Error() string {
+	return "unable to decode postmates response: " + e.Err.Error()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable reports whether err is transient and the call that caused it
+// is worth repeating, assuming the call has no side effects.
+func IsRetryable(err error) bool {
+	return err != nil && DefaultRetryPolicy.retryable(readCall, err)
+}
+
+// IsNotFound reports whether the api could not find the requested resource.
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.Is(err, ErrNotFound) || (errors.As(err, &e) && e.StatusCode == http.StatusNotFound)
+}
+
+// IsAddressProblem reports whether the api rejected an address as unknown or undeliverable.
+func IsAddressProblem(err error) bool {
+	return errors.Is(err, ErrUnknownLocation) || errors.Is(err, ErrAddressUndeliverable)
+}
+
+// IsTransportError reports whether err is a network failure rather than an api rejection.
+func IsTransportError(err error) bool {
+	var e *TransportError
+	return errors.As(err, &e)
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid request: " + e.Field + " " + e.Message
+}
@@ -1,8 +1,16 @@
 package ghostmates
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestError(t *testing.T) {
@@ -39,3 +47,212 @@ func TestError(t *testing.T) {
 	}
 
 }
+
+func TestNewErrorMetadata(t *testing.T) {
+
+	resp := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Status:     "429 TOO MANY REQUESTS",
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"kind":"error","code":"request_rate_limit_exceeded","message":"slow down"}`)),
+	}
+	resp.Header.Set(RequestIDHeader, "req_test")
+	resp.Header.Set("Retry-After", "30")
+	resp.Header.Set(RateLimitRemainingHeader, "0")
+	resp.Header.Set(RateLimitResetHeader, "1432496837")
+
+	e := NewError(resp)
+
+	if e.Code != ErrorCodeRequestRateLimitExceeded {
+		t.Errorf("Expected %q, got %q", ErrorCodeRequestRateLimitExceeded, e.Code)
+	}
+	if e.RequestID != "req_test" {
+		t.Errorf("Expected %q, got %q", "req_test", e.RequestID)
+	}
+	if e.RetryAfter != 30*time.Second {
+		t.Errorf("Expected 30s, got %s", e.RetryAfter)
+	}
+	if e.RateLimitRemaining != 0 {
+		t.Errorf("Expected 0, got %d", e.RateLimitRemaining)
+	}
+	if !e.RateLimitReset.Equal(time.Unix(1432496837, 0)) {
+		t.Errorf("Expected %s, got %s", time.Unix(1432496837, 0), e.RateLimitReset)
+	}
+	if len(e.Body) != 0 {
+		t.Errorf("Expected no raw body for a json error, got %q", e.Body)
+	}
+
+}
+
+func TestNewErrorUnparseable(t *testing.T) {
+
+	html := "<html><body>" + strings.Repeat("bad gateway ", MaxErrorBodyLength) + "</body></html>"
+
+	e := NewError(&http.Response{
+		StatusCode: http.StatusBadGateway,
+		Status:     "502 Bad Gateway",
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(html)),
+	})
+
+	if e.RateLimitRemaining != -1 {
+		t.Errorf("Expected -1 when not reported, got %d", e.RateLimitRemaining)
+	}
+	if len(e.Body) != MaxErrorBodyLength || !strings.HasPrefix(string(e.Body), "<html><body>bad gateway") {
+		t.Errorf("Expected the truncated html body, got %d bytes", len(e.Body))
+	}
+	if !strings.HasPrefix(e.Error(), `Postmates API Error (502 502 Bad Gateway) Body: "<html>`) {
+		t.Errorf("Unexpected Error() output %q", e.Error())
+	}
+
+}
+
+func TestErrorClassification(t *testing.T) {
+
+	for code, sentinel := range errorCodes {
+		e := &Error{StatusCode: http.StatusBadRequest, Kind: ErrorKind, Code: code}
+		err := fmt.Errorf("wrapped: %w", e)
+		if !errors.Is(err, sentinel) {
+			t.Errorf("Expected %q to match its sentinel", code)
+		}
+		if errors.Is(err, ErrNotFound) != (code == ErrorCodeNotFound) {
+			t.Errorf("Expected %q to match ErrNotFound only if not_found", code)
+		}
+		if IsAddressProblem(err) != (code == ErrorCodeUnknownLocation || code == ErrorCodeAddressUndeliverable) {
+			t.Errorf("Unexpected IsAddressProblem result for %q", code)
+		}
+		if IsRetryable(err) != (code == ErrorCodeServiceUnavailable || code == ErrorCodeRequestRateLimitExceeded) {
+			t.Errorf("Unexpected IsRetryable result for %q", code)
+		}
+		if IsTransportError(err) {
+			t.Errorf("Expected %q not to be a transport error", code)
+		}
+	}
+
+	if !IsNotFound(&Error{StatusCode: http.StatusNotFound}) {
+		t.Errorf("Expected a bare 404 to be not found")
+	}
+	if IsRetryable(nil) || IsNotFound(nil) || IsAddressProblem(nil) || IsTransportError(nil) {
+		t.Errorf("Expected nil to match nothing")
+	}
+
+}
+
+func TestTransportAndDecodeErrors(t *testing.T) {
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`<html>not json</html>`))
+	})
+
+	_, err := client.GetDelivery("del_test")
+	var de *DecodeError
+	if !errors.As(err, &de) || IsTransportError(err) {
+		t.Errorf("Expected a *DecodeError, got %T %v", err, err)
+	}
+
+	// nothing listening
+	s := httptest.NewServer(http.NotFoundHandler())
+	s.Close()
+
+	client, _ = NewClientWithOptions(TestCustomerId, TestAPIKey, WithBaseURL(s.URL), WithRetryPolicy(NoRetries))
+	_, err = client.GetDelivery("del_test")
+	if !IsTransportError(err) {
+		t.Errorf("Expected a *TransportError, got %T %v", err, err)
+	}
+	if !IsRetryable(err) {
+		t.Errorf("Expected a refused connection to be retryable")
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		t.Errorf("Expected no *Error")
+	}
+
+}
+
+func TestFieldErrors(t *testing.T) {
+
+	e := NewError(&http.Response{
+		StatusCode: http.StatusBadRequest,
+		Status:     "400 BAD REQUEST",
+		Header:     http.Header{},
+		Body: ioutil.NopCloser(strings.NewReader(`{"kind":"error","code":"invalid_params","message":"The parameters of your request were invalid.","params":{
+			"dropoff_phone_number":"This field is required.",
+			"manifest":["This field is required.","Must be descriptive."],
+			"pickup_latitude":"Invalid latitude.",
+			"mystery_field":"Not a thing."
+		}}`)),
+	})
+
+	fe := AsFieldErrors(fmt.Errorf("wrapped: %w", e))
+
+	expected := FieldErrors{
+		"Dropoff.PhoneNumber":  []string{"This field is required."},
+		"Manifest.Description": []string{"This field is required.", "Must be descriptive."},
+		"PickupLocation.Lat":   []string{"Invalid latitude."},
+		"mystery_field":        []string{"Not a thing."},
+	}
+	if !reflect.DeepEqual(fe, expected) {
+		t.Errorf("Expected %v, got %v", expected, fe)
+	}
+	if fields := strings.Join(fe.Fields(), ","); fields != "Dropoff.PhoneNumber,Manifest.Description,PickupLocation.Lat,mystery_field" {
+		t.Errorf("Unexpected sorted fields %q", fields)
+	}
+
+	// local validation failures use the same field names
+	dr := newTestDeliveryRequest()
+	dr.Dropoff.PhoneNumber = ""
+	if fe := AsFieldErrors(dr.Validate()); len(fe["Dropoff.PhoneNumber"]) != 1 {
+		t.Errorf("Expected a Dropoff.PhoneNumber error, got %v", fe)
+	}
+
+	if fe := AsFieldErrors(ErrNotFound); fe != nil {
+		t.Errorf("Expected nil, got %v", fe)
+	}
+
+}
+
+func TestFieldErrorsPerRequest(t *testing.T) {
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "GET" {
+			w.Write([]byte(`{"kind":"delivery","id":"del_test","status":"pickup"}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"kind":"error","code":"invalid_params","params":{"pickup_address":"Unknown address.","dropoff_phone_number":"Invalid phone number.","tip_by_customer":"Too large."}}`))
+	}, WithRetryPolicy(NoRetries))
+
+	// quotes name QuoteRequest fields, from the api and local validation alike
+	_, err := client.GetQuoteRequestContext(context.Background(), NewQuoteRequest(TestPickupAddress, TestDropoffAddress))
+	if fe := AsFieldErrors(err); len(fe["PickupAddress"]) != 1 || len(fe["DropoffPhoneNumber"]) != 1 {
+		t.Errorf("Expected QuoteRequest fields, got %v", fe)
+	}
+	if fe := AsFieldErrors(NewQuoteRequest("", TestDropoffAddress).Validate()); len(fe["PickupAddress"]) != 1 {
+		t.Errorf("Expected a PickupAddress error, got %v", fe)
+	}
+
+	// updates name DeliveryUpdate fields
+	_, err = client.UpdateDelivery("del_test", DeliveryUpdate{DropoffPhoneNumber: "555", Tip: 100000})
+	if fe := AsFieldErrors(err); len(fe["DropoffPhoneNumber"]) != 1 || len(fe["Tip"]) != 1 {
+		t.Errorf("Expected DeliveryUpdate fields, got %v", fe)
+	}
+	if fe := AsFieldErrors((&DeliveryUpdate{Tip: -1}).Validate()); len(fe["Tip"]) != 1 {
+		t.Errorf("Expected a Tip error, got %v", fe)
+	}
+
+	// deliveries, including the requote made on their behalf, name DeliveryRequest fields
+	dr := newTestDeliveryRequest()
+	_, err = client.PlaceDeliveryRequestContext(context.Background(), dr)
+	if fe := AsFieldErrors(err); len(fe["Pickup.Address"]) != 1 || len(fe["Dropoff.PhoneNumber"]) != 1 {
+		t.Errorf("Expected DeliveryRequest fields, got %v", fe)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	dr.Quote = &DeliveryQuote{ID: "dqt_expired", Expires: &expired}
+	dr.Requote = &RequoteTolerance{MaxFeeIncrease: 100}
+	_, err = client.PlaceDeliveryRequestContext(context.Background(), dr)
+	if fe := AsFieldErrors(err); len(fe["Pickup.Address"]) != 1 {
+		t.Errorf("Expected DeliveryRequest fields from the requote, got %v", fe)
+	}
+
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -16,7 +17,10 @@ import (
 	"github.com/jasonmoo/ghostmates"
 )
 
-const PostmatesClientTimeout = 5 * time.Second
+const (
+	PostmatesClientTimeout = 5 * time.Second
+	PostmatesRateLimit     = 10 // requests per second per account
+)
 
 var (
 	host = flag.String("host", ":8080", "host:port to listen on")
@@ -25,6 +29,12 @@ var (
 
 	PostmatesAPIKey    = os.Getenv("POSTMATES_API_KEY")
 	GoogleSearchAPIKey = os.Getenv("GOOGLE_API_KEY")
+
+	// every donor shares our api key, a real platform would look up each
+	// merchant's credentials here
+	clients = ghostmates.NewClientPool(func(ctx context.Context, customer_id string) (ghostmates.Credentials, error) {
+		return ghostmates.Credentials{CustomerID: customer_id, APIKey: PostmatesAPIKey}, nil
+	}, PostmatesRateLimit, PostmatesRateLimit, ghostmates.WithTimeout(PostmatesClientTimeout))
 )
 
 func main() {
@@ -70,7 +80,11 @@ func main() {
 			return
 		}
 
-		client := ghostmates.NewClient(PostmatesAPIKey, customer_id, PostmatesClientTimeout)
+		client, err := clients.Get(req.Context(), customer_id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
 		quote, err := client.GetQuote(pickup_address, nearby.Results[0].FormattedAddress)
 		if err != nil {
@@ -82,11 +96,19 @@ func main() {
 		pickup := ghostmates.NewDeliverySpot("Donation pickup spot", pickup_address, phone)
 		dropoff := ghostmates.NewDeliverySpot(nearby.Results[0].Name, nearby.Results[0].FormattedAddress, phone)
 
-		if err := client.CreateDelivery(manifest, pickup, dropoff, quote); err != nil {
+		delivery, err := client.PlaceDelivery(manifest, pickup, dropoff, quote)
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
+		pickup_eta := "soon"
+		if delivery.PickupEta != nil {
+			pickup_eta = "at " + delivery.PickupEta.Format(time.Kitchen)
+		}
+
+		fmt.Fprintf(w, "Delivery %s is %s, pickup expected %s\n", delivery.ID, delivery.Status, pickup_eta)
+
 	})
 
 	log.Fatal(http.ListenAndServeTLS(*host, *cert, *key, nil))
@@ -0,0 +1,259 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type (
+	// DeliveryIterator lists deliveries a page at a time, fetching the next
+	// page only once the current one has been consumed.
+	//
+	//	it := client.IterDeliveries(ctx, AllFilter)
+	//	for it.Next() {
+	//		d := it.Delivery()
+	//	}
+	//	if err := it.Err(); err != nil {
+	//		// resume later with client.ResumeDeliveries(ctx, it.Cursor())
+	//	}
+	DeliveryIterator struct {
+		client *Client
+		ctx    context.Context
+		opts   *ListOptions // client side filters, may be nil
+		next   string       // href of the next page, empty once the last page is fetched
+		href   string       // href of the current page
+		page   []*Delivery  // unconsumed remainder of the current page
+		offset int          // deliveries consumed from the current page
+		skip   int          // deliveries to skip on the first page fetched, from a cursor
+		cur    *Delivery
+		err    error
+
+		pages int             // pages fetched so far
+		seen  map[string]bool // pages fetched so far, to catch cycles
+	}
+)
+
+const (
+	DefaultMaxPages = 1000 // 1000 pages is well beyond any sane scan
+)
+
+var (
+	ErrInvalidNextHref = errors.New("next_href leaves the deliveries endpoint")
+	ErrPaginationLoop  = errors.New("next_href repeats a page already fetched")
+	ErrTooManyPages    = errors.New("too many pages")
+)
+
+// WithMaxPages stops delivery scans with ErrTooManyPages after n pages,
+// DefaultMaxPages by default.  Zero or less removes the limit.
+func WithMaxPages(n int) Option {
+	return func(c *Client) error {
+		c.max_pages = n
+		return nil
+	}
+}
+
+// IterDeliveries returns an iterator over deliveries matching filter.
+func (c *Client) IterDeliveries(ctx context.Context, filter string) *DeliveryIterator {
+	return c.IterDeliveriesWithOptions(ctx, &ListOptions{Filter: filter})
+}
+
+// IterDeliveriesWithOptions returns an iterator over deliveries matching opts.
+// A nil opts lists all deliveries.
+func (c *Client) IterDeliveriesWithOptions(ctx context.Context, opts *ListOptions) *DeliveryIterator {
+
+	// GET /v1/customers/:customer_id/deliveries
+	// This endpoint currently supports one query argument:
+	// ?filter=ongoing
+
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+
+	return c.ResumeDeliveriesWithOptions(ctx, c.customerPath("deliveries")+"?filter="+url.QueryEscape(opts.Filter), opts)
+
+}
+
+// ResumeDeliveries continues a scan from a cursor returned by DeliveryIterator.Cursor.
+func (c *Client) ResumeDeliveries(ctx context.Context, cursor string) *DeliveryIterator {
+	return c.ResumeDeliveriesWithOptions(ctx, cursor, nil)
+}
+
+// ResumeDeliveriesWithOptions continues a scan from a cursor, applying the
+// client side options in opts.  opts.Filter is already part of the cursor.
+func (c *Client) ResumeDeliveriesWithOptions(ctx context.Context, cursor string, opts *ListOptions) *DeliveryIterator {
+
+	// a cursor taken mid page carries the offset into it as the fragment
+	href, offset, _ := strings.Cut(cursor, "#")
+	skip, _ := strconv.Atoi(offset)
+
+	return &DeliveryIterator{
+		client: c,
+		ctx:    ctx,
+		opts:   opts,
+		next:   href,
+		skip:   skip,
+		seen:   map[string]bool{},
+	}
+
+}
+
+// Next advances to the next matching delivery, fetching pages as needed.
+// It returns false when there are no more deliveries or an error occurred.
+func (it *DeliveryIterator) Next() bool {
+
+	for {
+
+		if it.err != nil {
+			return false
+		}
+		for len(it.page) == 0 {
+			if !it.fetch() {
+				return false
+			}
+		}
+
+		d := it.page[0]
+		it.page = it.page[1:]
+		it.offset++
+
+		// everything from here on is older than asked for
+		if it.opts.exhausted(d) {
+			it.page, it.next = nil, ""
+			return false
+		}
+
+		if it.opts.Match(d) {
+			it.cur = d
+			return true
+		}
+
+	}
+
+}
+
+// fetch loads the next page, returning false when there isn't one
+func (it *DeliveryIterator) fetch() bool {
+
+	if len(it.next) == 0 {
+		return false
+	}
+
+	// next_href comes from the response body, make sure it's sane
+	// before sending our credentials along with it
+	u, err := it.client.pageHref(it.next)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	if it.seen[u] {
+		it.err = fmt.Errorf("%w: %q", ErrPaginationLoop, it.next)
+		return false
+	}
+	if max := it.client.max_pages; max > 0 && it.pages >= max {
+		it.err = fmt.Errorf("%w: stopped after %d", ErrTooManyPages, max)
+		return false
+	}
+	it.seen[u] = true
+	it.pages++
+
+	ds := &Deliveries{}
+	if err := it.client.do(it.ctx, readCall, "GET", u, nil, ds); err != nil {
+		// it.next is left pointing at the failed page so it can be resumed
+		it.err = err
+		return false
+	}
+
+	// sanity check ds.Data length, an empty page ends the scan
+	it.href, it.page, it.next, it.offset = it.next, ds.Data, ds.NextHref, 0
+	if len(it.page) == 0 {
+		it.next = ""
+		return false
+	}
+
+	// resuming mid page, the page may now be consumed
+	if it.skip > 0 {
+		n := min(it.skip, len(it.page))
+		it.page, it.offset, it.skip = it.page[n:], n, 0
+	}
+
+	return true
+
+}
+
+// pageHref validates a page href and returns it relative to the base url.
+// Absolute hrefs must point at the configured api host, and every href must
+// point at this customer's deliveries endpoint.
+func (c *Client) pageHref(href string) (string, error) {
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q: %s", ErrInvalidNextHref, href, err)
+	}
+
+	if u.IsAbs() || len(u.Host) > 0 {
+		if u.Scheme != c.base.Scheme || u.Host != c.base.Host || u.User != nil {
+			return "", fmt.Errorf("%w: %q is not on %s", ErrInvalidNextHref, href, c.base.Host)
+		}
+		// absolute hrefs include the base path prefix, relative ones don't
+		if prefix := strings.TrimRight(c.base.Path, "/"); len(prefix) > 0 {
+			if !strings.HasPrefix(u.Path, prefix+"/") {
+				return "", fmt.Errorf("%w: %q is not under %s", ErrInvalidNextHref, href, c.base)
+			}
+			u.Path = strings.TrimPrefix(u.Path, prefix)
+			u.RawPath = strings.TrimPrefix(u.RawPath, prefix)
+		}
+	}
+
+	if u.EscapedPath() != c.customerPath("deliveries") && u.Path != "/v1/customers/"+c.customer_id+"/deliveries" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidNextHref, href)
+	}
+
+	return (&url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery}).String(), nil
+
+}
+
+// Delivery returns the current delivery.
+func (it *DeliveryIterator) Delivery() *Delivery {
+	return it.cur
+}
+
+// Err returns the error that stopped iteration, if any.
+func (it *DeliveryIterator) Err() error {
+	return it.err
+}
+
+// Cursor returns where to resume the scan from, or "" once every delivery
+// has been returned.  Part way through a page it points at the current page
+// and how much of it was consumed, otherwise at the next page to be fetched.
+// After an error it points at the page that failed.  Pages list newest
+// first, so deliveries created in the meantime can shift the page.
+func (it *DeliveryIterator) Cursor() string {
+	if len(it.page) > 0 {
+		return it.href + "#" + strconv.Itoa(it.offset)
+	}
+	// a resumed page that hasn't loaded yet keeps its offset
+	if it.skip > 0 && len(it.next) > 0 {
+		return it.next + "#" + strconv.Itoa(it.skip)
+	}
+	return it.next
+}
+
+// All adapts the iterator to a range-over-func sequence.  An error ends the
+// sequence as a final (nil, err) pair.
+func (it *DeliveryIterator) All() iter.Seq2[*Delivery, error] {
+	return func(yield func(*Delivery, error) bool) {
+		for it.Next() {
+			if !yield(it.Delivery(), nil) {
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
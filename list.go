@@ -0,0 +1,102 @@
+package ghostmates
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// ListOptions narrows a delivery listing.  Filter is applied by the api,
+	// everything else is applied client side as pages arrive.  Zero values
+	// don't filter.
+	ListOptions struct {
+		Filter string // OngoingFilter or AllFilter
+
+		CreatedAfter  time.Time // Deliveries are listed newest first, paging stops at the first one created before this
+		CreatedBefore time.Time
+		UpdatedAfter  time.Time
+		UpdatedBefore time.Time
+
+		Statuses          []Status               // Any of these statuses
+		LiveMode          *bool                  // Only live or only test deliveries
+		ManifestReference string                 // Exact match on Manifest.Reference
+		Predicate         func(d *Delivery) bool // Arbitrary test, applied after the others
+	}
+)
+
+// ListDeliveries returns up to n deliveries matching opts, or all of them if
+// n == AllDeliveries.  A nil opts matches every delivery.
+func (c *Client) ListDeliveries(ctx context.Context, opts *ListOptions, n int) ([]*Delivery, error) {
+
+	// pages are fetched lazily so we stop paging as soon as we have n
+	var (
+		da []*Delivery
+		it = c.IterDeliveriesWithOptions(ctx, opts)
+	)
+
+	// if n == AllDeliveries we go till the wheels fall off
+	for (n == AllDeliveries || len(da) < n) && it.Next() {
+		da = append(da, it.Delivery())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return da, nil
+
+}
+
+// Match reports whether d passes every client side option.  A nil
+// *ListOptions matches everything.
+func (lo *ListOptions) Match(d *Delivery) bool {
+
+	if lo == nil {
+		return true
+	}
+
+	if !within(d.Created, lo.CreatedAfter, lo.CreatedBefore) || !within(d.Updated, lo.UpdatedAfter, lo.UpdatedBefore) {
+		return false
+	}
+
+	if len(lo.Statuses) > 0 {
+		found := false
+		for _, s := range lo.Statuses {
+			if d.Status == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if lo.LiveMode != nil && d.LiveMode != *lo.LiveMode {
+		return false
+	}
+
+	if len(lo.ManifestReference) > 0 && d.Manifest.Reference != lo.ManifestReference {
+		return false
+	}
+
+	return lo.Predicate == nil || lo.Predicate(d)
+
+}
+
+// exhausted reports whether d, and so everything listed after it, was
+// created before the requested range
+func (lo *ListOptions) exhausted(d *Delivery) bool {
+	return lo != nil && !lo.CreatedAfter.IsZero() && d.Created != nil && d.Created.Before(lo.CreatedAfter)
+}
+
+// within reports whether t falls in [after, before).  A missing t only
+// passes an unbounded range.
+func within(t *time.Time, after, before time.Time) bool {
+	if after.IsZero() && before.IsZero() {
+		return true
+	}
+	if t == nil {
+		return false
+	}
+	return (after.IsZero() || !t.Before(after)) && (before.IsZero() || t.Before(before))
+}
@@ -0,0 +1,119 @@
+package ghostmates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestListDeliveries(t *testing.T) {
+
+	var (
+		now      = time.Date(2015, 5, 24, 12, 0, 0, 0, time.UTC)
+		statuses = []Status{StatusDelivered, StatusCanceled, StatusPending}
+		requests int
+	)
+
+	// 5 pages of 4 deliveries, one created every 6 hours going back from now
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+
+		requests++
+		page, _ := strconv.Atoi(req.FormValue("page"))
+
+		ds := &Deliveries{}
+		for i := page * 4; i < page*4+4; i++ {
+			created := now.Add(-time.Duration(i) * 6 * time.Hour)
+			ds.Data = append(ds.Data, &Delivery{
+				ID:       fmt.Sprintf("del_%d", i),
+				Status:   statuses[i%len(statuses)],
+				Created:  &created,
+				Updated:  &created,
+				Fee:      i * 500,
+				Manifest: Manifest{Reference: fmt.Sprintf("order-%d", i%2)},
+				LiveMode: i == 7,
+			})
+		}
+		if page < 4 {
+			ds.NextHref = req.URL.Path + "?page=" + strconv.Itoa(page+1)
+		}
+		json.NewEncoder(w).Encode(ds)
+
+	})
+
+	ids := func(da []*Delivery) string {
+		var s []string
+		for _, d := range da {
+			s = append(s, d.ID)
+		}
+		return fmt.Sprint(s)
+	}
+
+	// delivered yesterday, stops paging at the first delivery from the day before
+	yesterday := now.Add(-24 * time.Hour).Truncate(24 * time.Hour)
+	da, err := client.ListDeliveries(context.Background(), &ListOptions{
+		CreatedAfter:  yesterday,
+		CreatedBefore: yesterday.Add(24 * time.Hour),
+		Statuses:      []Status{StatusDelivered},
+	}, AllDeliveries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(da) != "[del_3 del_6]" {
+		t.Errorf("Unexpected deliveries %s", ids(da))
+	}
+	if requests != 2 {
+		t.Errorf("Expected paging to stop after 2 requests, got %d", requests)
+	}
+
+	// canceled with fee > $20
+	requests = 0
+	da, err = client.ListDeliveries(context.Background(), &ListOptions{
+		Statuses:  []Status{StatusCanceled},
+		Predicate: func(d *Delivery) bool { return d.Fee > 2000 },
+	}, AllDeliveries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(da) != "[del_7 del_10 del_13 del_16 del_19]" || requests != 5 {
+		t.Errorf("Unexpected deliveries %s from %d requests", ids(da), requests)
+	}
+
+	live := true
+	da, err = client.ListDeliveries(context.Background(), &ListOptions{LiveMode: &live, ManifestReference: "order-1"}, AllDeliveries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(da) != "[del_7]" {
+		t.Errorf("Unexpected deliveries %s", ids(da))
+	}
+
+	// n counts matches, not deliveries scanned
+	requests = 0
+	da, err = client.ListDeliveries(context.Background(), &ListOptions{ManifestReference: "order-0", UpdatedBefore: now}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(da) != "[del_2 del_4]" || requests != 2 {
+		t.Errorf("Unexpected deliveries %s from %d requests", ids(da), requests)
+	}
+
+}
+
+func TestListDeliveriesNilOptions(t *testing.T) {
+
+	handler, requests := pagedDeliveries(t, 2, 2, nil)
+	client := newTestClient(t, handler)
+
+	da, err := client.ListDeliveries(context.Background(), nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(da) != 3 || *requests != 2 {
+		t.Errorf("Expected 3 deliveries from 2 requests, got %d from %d", len(da), *requests)
+	}
+
+}
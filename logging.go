@@ -0,0 +1,213 @@
+package ghostmates
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// logging is the slog setup shared by Client and Webhook.  A nil logger
+	// disables logging.
+	logging struct {
+		logger      *slog.Logger
+		level       slog.Level // successful calls and accepted events
+		error_level slog.Level // failed calls and rejected events
+		pii         bool       // log names and phone numbers as-is
+	}
+)
+
+const redacted = "[redacted]"
+
+func defaultLogging() logging {
+	return logging{level: slog.LevelInfo, error_level: slog.LevelWarn}
+}
+
+// WithLogger logs every api call attempt to l: method, endpoint, status,
+// latency and postmates error code.  Request bodies are logged at debug level.
+// Names and phone numbers are redacted unless WithPIILogging(true) is set and
+// credentials are never logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) error {
+		c.log.logger = l
+		return nil
+	}
+}
+
+// WithLogLevels sets the levels successful and failed calls are logged at,
+// slog.LevelInfo and slog.LevelWarn by default.
+func WithLogLevels(ok, failed slog.Level) Option {
+	return func(c *Client) error {
+		c.log.level, c.log.error_level = ok, failed
+		return nil
+	}
+}
+
+// WithPIILogging disables redaction of names and phone numbers in the
+// client's logs.  Spots logged by the caller are always redacted.
+func WithPIILogging(enabled bool) Option {
+	return func(c *Client) error {
+		c.log.pii = enabled
+		return nil
+	}
+}
+
+// WithWebhookLogger logs every received event to l: kind, ids and a summary
+// of the delivery with names and phone numbers redacted, unless
+// WithWebhookPIILogging(true) is set.
+func WithWebhookLogger(l *slog.Logger) WebhookOption {
+	return func(wh *Webhook) {
+		wh.log.logger = l
+	}
+}
+
+// WithWebhookLogLevels sets the levels accepted and rejected events are
+// logged at, slog.LevelInfo and slog.LevelWarn by default.
+func WithWebhookLogLevels(ok, rejected slog.Level) WebhookOption {
+	return func(wh *Webhook) {
+		wh.log.level, wh.log.error_level = ok, rejected
+	}
+}
+
+// WithWebhookPIILogging disables redaction of names and phone numbers in the
+// webhook's event logs.  Spots logged by the caller are always redacted.
+func WithWebhookPIILogging(enabled bool) WebhookOption {
+	return func(wh *Webhook) {
+		wh.log.pii = enabled
+	}
+}
+
+// LogValue always redacts the contact name and phone number, whatever the
+// client's WithPIILogging setting, since a spot logged directly isn't tied
+// to a client.  The business name and address are kept.
+func (ds DeliverySpot) LogValue() slog.Value {
+	return spotValue(ds.Name, ds.BusinessName, ds.Address, ds.PhoneNumber, false)
+}
+
+// LogValue logs a spot returned by the api with its name and phone number
+// redacted.  Use the client's own logging, see WithPIILogging, to see them.
+func (s Spot) LogValue() slog.Value {
+	return spotValue(s.Name, "", s.Address, s.PhoneNumber, false)
+}
+
+func spotValue(name, business_name, address, phone_number string, pii bool) slog.Value {
+	if !pii {
+		name, phone_number = redact(name), redact(phone_number)
+	}
+	attrs := []slog.Attr{slog.String("name", name)}
+	if len(business_name) > 0 {
+		attrs = append(attrs, slog.String("business_name", business_name))
+	}
+	return slog.GroupValue(append(attrs, slog.String("address", address), slog.String("phone_number", phone_number))...)
+}
+
+func redact(v string) string {
+	if len(v) == 0 {
+		return v
+	}
+	return redacted
+}
+
+// call logs a single api call attempt
+func (lg *logging) call(ctx context.Context, method, u string, form url.Values, attempt int, latency time.Duration, err error) {
+
+	if lg.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("endpoint", endpoint(u)),
+		slog.Int("attempt", attempt),
+		slog.Duration("latency", latency),
+	}
+
+	level := lg.level
+	switch e := err.(type) {
+	case nil:
+		attrs = append(attrs, slog.Int("status", 200))
+	case *Error:
+		level = lg.error_level
+		attrs = append(attrs, slog.Int("status", e.StatusCode), slog.String("code", e.Code), slog.String("message", e.Message))
+		if len(e.RequestID) > 0 {
+			attrs = append(attrs, slog.String("request_id", e.RequestID))
+		}
+	default:
+		level = lg.error_level
+		attrs = append(attrs, slog.String("error", e.Error()))
+	}
+
+	lg.logger.LogAttrs(ctx, level, "postmates api call", attrs...)
+
+	if len(form) > 0 && lg.logger.Enabled(ctx, slog.LevelDebug) {
+		lg.logger.LogAttrs(ctx, slog.LevelDebug, "postmates api request", slog.String("endpoint", endpoint(u)), slog.Any("form", lg.form(form)))
+	}
+
+}
+
+// event logs a webhook event, or why it was rejected
+func (lg *logging) event(ctx context.Context, kind, id, delivery_id string, live_mode bool, d *Delivery, err error) {
+
+	if lg.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("kind", kind),
+		slog.String("id", id),
+		slog.String("delivery_id", delivery_id),
+		slog.Bool("live_mode", live_mode),
+	}
+	if d != nil {
+		attrs = append(attrs, slog.Any("delivery", lg.delivery(d)))
+	}
+
+	if err != nil {
+		lg.logger.LogAttrs(ctx, lg.error_level, "postmates webhook event rejected", append(attrs, slog.String("error", err.Error()))...)
+		return
+	}
+
+	lg.logger.LogAttrs(ctx, lg.level, "postmates webhook event", attrs...)
+
+}
+
+func (lg *logging) delivery(d *Delivery) slog.Value {
+	return slog.GroupValue(
+		slog.String("id", d.ID),
+		slog.String("status", string(d.Status)),
+		slog.Any("pickup", spotValue(d.Pickup.Name, "", d.Pickup.Address, d.Pickup.PhoneNumber, lg.pii)),
+		slog.Any("dropoff", spotValue(d.Dropoff.Name, "", d.Dropoff.Address, d.Dropoff.PhoneNumber, lg.pii)),
+	)
+}
+
+// form returns the request body with names and phone numbers redacted
+func (lg *logging) form(form url.Values) slog.Value {
+	keys := make([]string, 0, len(form))
+	for key := range form {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(form))
+	for _, key := range keys {
+		v := form.Get(key)
+		if !lg.pii && (strings.HasSuffix(key, "_phone_number") || (strings.HasSuffix(key, "_name") && !strings.HasSuffix(key, "_business_name"))) {
+			v = redact(v)
+		}
+		attrs = append(attrs, slog.String(key, v))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// endpoint strips anything resembling credentials from a request url
+func endpoint(u string) string {
+	p, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	p.User = nil
+	return p.String()
+}
@@ -0,0 +1,111 @@
+package ghostmates
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientLogging(t *testing.T) {
+
+	buf := &bytes.Buffer{}
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/delivery_quotes") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"kind":"error","code":"address_undeliverable","message":"nope"}`))
+			return
+		}
+		w.Write([]byte(`{"kind":"delivery","id":"del_test"}`))
+	}, WithLogger(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))), WithRetryPolicy(NoRetries))
+
+	if _, err := client.GetQuote(TestPickupAddress, TestDropoffAddress); err == nil {
+		t.Errorf("Expected an error")
+	}
+	if _, err := client.PlaceDeliveryRequestContext(context.Background(), newTestDeliveryRequest()); err != nil {
+		t.Error(err)
+	}
+
+	logs := buf.String()
+
+	for _, expected := range []string{
+		`level=WARN msg="postmates api call" method=POST endpoint=/v1/customers/` + TestCustomerId + `/delivery_quotes attempt=1`,
+		`status=400 code=address_undeliverable message=nope`,
+		`level=INFO msg="postmates api call" method=POST endpoint=/v1/customers/` + TestCustomerId + `/deliveries attempt=1`,
+		`status=200`,
+		`form.dropoff_name=[redacted]`,
+		`form.dropoff_phone_number=[redacted]`,
+		`form.pickup_address="` + TestPickupAddress + `"`,
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("Expected logs to contain %q\n%s", expected, logs)
+		}
+	}
+	for _, secret := range []string{TestAPIKey, TestDropoffPhoneNumber, TestDropoffName} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("Expected %q to be kept out of the logs\n%s", secret, logs)
+		}
+	}
+
+}
+
+func TestWebhookLogging(t *testing.T) {
+
+	var (
+		buf = &bytes.Buffer{}
+		wh  = NewWebhook(WithWebhookLogger(slog.New(slog.NewTextHandler(buf, nil))))
+		s   = httptest.NewServer(wh.Handler)
+	)
+
+	defer s.Close()
+
+	// status event followed by junk
+	for _, payload := range []string{TestDeliveryPayloads[1], `{"kind":"xxx"}`} {
+		resp, err := http.Post(s.URL, "application/json; charset=UTF-8", strings.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	logs := buf.String()
+
+	for _, expected := range []string{
+		`level=INFO msg="postmates webhook event" kind=event.delivery_status id=evt_KL7elR_sFyDrXV delivery_id=del_KL7ebUwT4XjFKF live_mode=false delivery.id=del_KL7ebUwT4XjFKF delivery.status=pickup delivery.pickup.name=[redacted]`,
+		`delivery.dropoff.phone_number=[redacted]`,
+		`level=WARN msg="postmates webhook event rejected" kind=xxx`,
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("Expected logs to contain %q\n%s", expected, logs)
+		}
+	}
+	if strings.Contains(logs, TestDropoffPhoneNumber) {
+		t.Errorf("Expected phone numbers to be kept out of the logs\n%s", logs)
+	}
+
+}
+
+func TestSpotLogValue(t *testing.T) {
+
+	buf := &bytes.Buffer{}
+	slog.New(slog.NewTextHandler(buf, nil)).Info("spot",
+		"dropoff", NewDeliverySpot(TestDropoffName, TestDropoffAddress, TestDropoffPhoneNumber),
+		"pickup", Spot{Name: TestPickupName, Address: TestPickupAddress, PhoneNumber: TestPickupPhoneNumber},
+	)
+
+	// redacted regardless of any client's pii setting
+	logs := buf.String()
+	for _, pii := range []string{TestDropoffName, TestDropoffPhoneNumber, TestPickupName, TestPickupPhoneNumber} {
+		if strings.Contains(logs, pii) {
+			t.Errorf("Expected %q to be redacted: %s", pii, logs)
+		}
+	}
+	if !strings.Contains(logs, "dropoff.name=[redacted]") || !strings.Contains(logs, "pickup.phone_number=[redacted]") {
+		t.Errorf("Expected redacted fields: %s", logs)
+	}
+
+}
@@ -0,0 +1,155 @@
+package ghostmates
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type (
+	// ManifestItem is one line of an itemized manifest.  Size tells the
+	// courier what vehicle the delivery needs.
+	ManifestItem struct {
+		Name     string `json:"name"`
+		Quantity int    `json:"quantity"`
+		Size     string `json:"size"`             // One of the Size* constants
+		Weight   int    `json:"weight,omitempty"` // Optional weight of one item in grams
+		Value    int    `json:"value,omitempty"`  // Optional value of one item in cents
+	}
+)
+
+const (
+	SizeSmall  = "small"  // Fits in a bag, eg. food or documents
+	SizeMedium = "medium" // Fits on a bike, eg. a box of shoes
+	SizeLarge  = "large"  // Needs a car, eg. a microwave
+	SizeXLarge = "xlarge" // Needs a truck or van, eg. furniture
+)
+
+// Text describes the manifest in free text: the description followed by the
+// items, for api versions that only accept text.
+func (m *Manifest) Text() string {
+
+	if len(m.Items) == 0 {
+		return m.Description
+	}
+
+	items := make([]string, len(m.Items))
+	for i, item := range m.Items {
+		items[i] = item.String()
+	}
+	list := strings.Join(items, ", ")
+
+	if len(m.Description) == 0 {
+		return list
+	}
+
+	return m.Description + ": " + list
+
+}
+
+// String renders the item as eg. "2 x Bookshelf (xlarge, 25.5kg, 120.00)".
+func (mi ManifestItem) String() string {
+
+	details := []string{mi.Size}
+	if mi.Weight > 0 {
+		details = append(details, strconv.FormatFloat(float64(mi.Weight)/1000, 'f', -1, 64)+"kg")
+	}
+	if mi.Value > 0 {
+		details = append(details, fmt.Sprintf("%d.%02d", mi.Value/100, mi.Value%100))
+	}
+
+	return fmt.Sprintf("%d x %s (%s)", mi.Quantity, mi.Name, strings.Join(details, ", "))
+
+}
+
+// validateItems returns a *ValidationError for the first invalid item
+func (m *Manifest) validateItems() error {
+
+	for i, item := range m.Items {
+
+		field := func(name string) string {
+			return fmt.Sprintf("Manifest.Items[%d].%s", i, name)
+		}
+
+		if len(item.Name) == 0 {
+			return &ValidationError{Field: field("Name"), Message: "is required"}
+		}
+		if item.Quantity < 1 {
+			return &ValidationError{Field: field("Quantity"), Message: "must be at least 1"}
+		}
+		switch item.Size {
+		case SizeSmall, SizeMedium, SizeLarge, SizeXLarge:
+		default:
+			return &ValidationError{Field: field("Size"), Message: "must be " + SizeSmall + ", " + SizeMedium + ", " + SizeLarge + " or " + SizeXLarge}
+		}
+		if item.Weight < 0 {
+			return &ValidationError{Field: field("Weight"), Message: "must not be negative"}
+		}
+		if item.Value < 0 {
+			return &ValidationError{Field: field("Value"), Message: "must not be negative"}
+		}
+
+	}
+
+	return nil
+
+}
+
+// WithManifestItems sends itemized manifests as json in manifest_items.
+// Only enable it for accounts whose api version accepts manifest_items, by
+// default items are folded into the manifest text every version accepts.
+func WithManifestItems() Option {
+	return func(c *Client) error {
+		c.manifest_items = true
+		return nil
+	}
+}
+
+// setManifest encodes m.  With items set they are sent as json in
+// manifest_items, alongside the description as given, otherwise they are
+// folded into the manifest text.
+func (f form) setManifest(m *Manifest, items bool) {
+
+	f.set("manifest_reference", m.Reference)
+
+	if !items || len(m.Items) == 0 {
+		f.set("manifest", m.Text())
+		return
+	}
+
+	// the api still requires a description
+	if len(m.Description) > 0 {
+		f.set("manifest", m.Description)
+	} else {
+		f.set("manifest", m.Text())
+	}
+
+	data, _ := json.Marshal(m.Items)
+	url.Values(f).Set("manifest_items", string(data))
+
+}
+
+// UnmarshalJSON also accepts manifest items at the top level of the
+// delivery, where the api returns them, as Manifest.Items.
+func (d *Delivery) UnmarshalJSON(data []byte) error {
+
+	type delivery Delivery
+
+	v := struct {
+		*delivery
+		ManifestItems []ManifestItem `json:"manifest_items"`
+	}{delivery: (*delivery)(d)}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if len(d.Manifest.Items) == 0 {
+		d.Manifest.Items = v.ManifestItems
+	}
+
+	return nil
+
+}
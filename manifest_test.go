@@ -0,0 +1,142 @@
+package ghostmates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+var TestManifestItems = []ManifestItem{
+	{Name: "Bookshelf", Quantity: 1, Size: SizeXLarge, Weight: 25500, Value: 12000},
+	{Name: "Shelf pins", Quantity: 16, Size: SizeSmall},
+}
+
+func TestItemizedManifest(t *testing.T) {
+
+	dr := newTestDeliveryRequest()
+	dr.Manifest.Items = TestManifestItems
+
+	if err := dr.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// by default items are folded into the text
+	v := dr.Values()
+
+	expected := TestManifestDescription + ": 1 x Bookshelf (xlarge, 25.5kg, 120.00), 16 x Shelf pins (small)"
+	if v.Get("manifest") != expected || v.Has("manifest_items") {
+		t.Errorf("Expected %q and no manifest_items, got %v", expected, v)
+	}
+
+	// or sent as they are
+	v = dr.ValuesWithManifestItems()
+
+	if v.Get("manifest") != TestManifestDescription {
+		t.Errorf("Expected %q, got %q", TestManifestDescription, v.Get("manifest"))
+	}
+	var items []ManifestItem
+	if err := json.Unmarshal([]byte(v.Get("manifest_items")), &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0] != TestManifestItems[0] {
+		t.Errorf("Unexpected manifest_items %s", v.Get("manifest_items"))
+	}
+
+	// items alone are enough of a description
+	dr.Manifest.Description = ""
+	if err := dr.Validate(); err != nil {
+		t.Error(err)
+	}
+	for _, v := range []url.Values{dr.Values(), dr.ValuesWithManifestItems()} {
+		if v.Get("manifest") != "1 x Bookshelf (xlarge, 25.5kg, 120.00), 16 x Shelf pins (small)" {
+			t.Errorf("Unexpected manifest %q", v.Get("manifest"))
+		}
+	}
+
+	for field, item := range map[string]ManifestItem{
+		"Manifest.Items[0].Name":     {Quantity: 1, Size: SizeSmall},
+		"Manifest.Items[0].Quantity": {Name: "Lamp", Size: SizeSmall},
+		"Manifest.Items[0].Size":     {Name: "Lamp", Quantity: 1, Size: "huge"},
+		"Manifest.Items[0].Weight":   {Name: "Lamp", Quantity: 1, Size: SizeSmall, Weight: -1},
+	} {
+		dr.Manifest.Items = []ManifestItem{item}
+		var ve *ValidationError
+		if err := dr.Validate(); !errors.As(err, &ve) || ve.Field != field {
+			t.Errorf("Expected a validation error for %s, got %v", field, err)
+		}
+	}
+
+}
+
+func TestDeliveryManifestItems(t *testing.T) {
+
+	d := &Delivery{}
+	if err := json.Unmarshal([]byte(`{"id":"del_test","manifest":{"description":"Furniture"},"manifest_items":[{"name":"Bookshelf","quantity":1,"size":"xlarge"}]}`), d); err != nil {
+		t.Fatal(err)
+	}
+	if d.ID != "del_test" || d.Manifest.Description != "Furniture" || len(d.Manifest.Items) != 1 || d.Manifest.Items[0].Size != SizeXLarge {
+		t.Errorf("Unexpected delivery %+v", d)
+	}
+
+	// and survives a round trip
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d = &Delivery{}
+	if err := json.Unmarshal(data, d); err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Manifest.Items) != 1 || d.Manifest.Items[0].Name != "Bookshelf" {
+		t.Errorf("Unexpected round trip %s", data)
+	}
+
+}
+
+func TestItemizedManifestClient(t *testing.T) {
+
+	var form url.Values
+
+	for _, items := range []bool{false, true} {
+
+		opts := []Option{}
+		if items {
+			opts = append(opts, WithManifestItems())
+		}
+
+		client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+			req.ParseForm()
+			form = req.PostForm
+			w.Write([]byte(`{"kind":"delivery","id":"del_test"}`))
+		}, opts...)
+
+		dr := newTestDeliveryRequest()
+		dr.Manifest.Items = TestManifestItems
+
+		if _, err := client.PlaceDeliveryRequestContext(context.Background(), dr); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := TestManifestDescription + ": 1 x Bookshelf (xlarge, 25.5kg, 120.00), 16 x Shelf pins (small)"
+		if items {
+			expected = TestManifestDescription
+		}
+		if form.Get("manifest") != expected || form.Get("manifest_reference") != TestManifestReference {
+			t.Errorf("manifest_items %t: unexpected form %v", items, form)
+		}
+
+		var sent []ManifestItem
+		if items {
+			if err := json.Unmarshal([]byte(form.Get("manifest_items")), &sent); err != nil || len(sent) != 2 || sent[1] != TestManifestItems[1] {
+				t.Errorf("Unexpected manifest_items %q %v", form.Get("manifest_items"), err)
+			}
+		} else if form.Has("manifest_items") {
+			t.Errorf("Expected no manifest_items, got %q", form.Get("manifest_items"))
+		}
+
+	}
+
+}
@@ -0,0 +1,187 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type (
+	// Mode is the postmates account mode a Client or Webhook expects to be
+	// working with, checked against the live_mode flag of responses and events.
+	Mode int
+
+	// ModeMismatchError reports a response or event in the wrong mode.  When
+	// the call that detected it was a write, Delivery is the delivery the api
+	// created or changed so it can be cancelled.
+	ModeMismatchError struct {
+		Expected Mode
+		Got      Mode
+		What     string    // eg. "delivery del_123"
+		Delivery *Delivery // set when the mismatch was found on a returned delivery
+	}
+)
+
+const (
+	ModeAny  Mode = iota // accept live and test data, the default
+	ModeLive             // real deliveries only
+	ModeTest             // test deliveries only
+)
+
+var (
+	ErrLiveModeMismatch = errors.New("live mode mismatch")
+)
+
+// WithMode refuses responses whose deliveries are not in mode m.  The first
+// mismatch is returned as a *ModeMismatchError matching ErrLiveModeMismatch
+// and every later call fails with it without being sent, since the client's
+// key is for the wrong account.
+//
+// Before its first write the client lists a page of deliveries to learn the
+// account's mode, so a mismatched key is refused before anything is created.
+// An account with no deliveries yet can't be checked that way; if such a
+// write turns out to be in the wrong mode the error carries the delivery.
+func WithMode(m Mode) Option {
+	return func(c *Client) error {
+		if m < ModeAny || m > ModeTest {
+			return fmt.Errorf("invalid mode %d", m)
+		}
+		c.mode = m
+		return nil
+	}
+}
+
+// WithWebhookMode rejects events, and events carrying deliveries, that are
+// not in mode m.
+func WithWebhookMode(m Mode) WebhookOption {
+	return func(wh *Webhook) {
+		wh.mode = m
+	}
+}
+
+func (m Mode) String() string {
+	switch m {
+	case ModeAny:
+		return "any"
+	case ModeLive:
+		return "live"
+	case ModeTest:
+		return "test"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+func (e *ModeMismatchError) Error() string {
+	return fmt.Sprintf("%s: expected %s mode, %s is in %s mode", ErrLiveModeMismatch, e.Expected, e.What, e.Got)
+}
+
+func (e *ModeMismatchError) Unwrap() error {
+	return ErrLiveModeMismatch
+}
+
+// check returns a *ModeMismatchError if the live_mode flag of what doesn't match m
+func (m Mode) check(what string, live bool) *ModeMismatchError {
+
+	if m == ModeAny || live == (m == ModeLive) {
+		return nil
+	}
+
+	got := ModeTest
+	if live {
+		got = ModeLive
+	}
+
+	return &ModeMismatchError{Expected: m, Got: got, What: what}
+
+}
+
+// checkResponse checks every delivery in a decoded api response.  seen
+// reports whether there was a delivery to check.
+func (m Mode) checkResponse(v interface{}) (seen bool, err error) {
+	switch v := v.(type) {
+	case *Delivery:
+		if e := m.check("delivery "+v.ID, v.LiveMode); e != nil {
+			e.Delivery = v
+			return true, e
+		}
+		return true, nil
+	case *Deliveries:
+		for _, d := range v.Data {
+			if e := m.check("delivery "+d.ID, d.LiveMode); e != nil {
+				return true, e
+			}
+		}
+		return len(v.Data) > 0, nil
+	}
+	return false, nil
+}
+
+// checkEvent checks a webhook event and the delivery it carries
+func (m Mode) checkEvent(id string, live bool, d *Delivery) error {
+	if err := m.check("event "+id, live); err != nil {
+		return err
+	}
+	if d != nil {
+		if err := m.check("delivery "+d.ID, d.LiveMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMode checks a decoded response against the client's mode, latching
+// the first mismatch.  A response with matching deliveries settles the
+// account's mode so writes skip verifyMode.
+func (c *Client) checkMode(v interface{}) error {
+
+	seen, err := c.mode.checkResponse(v)
+
+	c.mode_mu.Lock()
+	defer c.mode_mu.Unlock()
+
+	if err != nil {
+		if c.mode_err == nil {
+			// later calls didn't touch the delivery, don't hand it out again
+			latched := *err.(*ModeMismatchError)
+			latched.Delivery = nil
+			c.mode_err = &latched
+		}
+		return err
+	}
+	if seen {
+		c.mode_ok = true
+	}
+
+	return nil
+
+}
+
+// verifyMode lists a page of deliveries to learn the account's mode before
+// the first write.  An empty account leaves it unsettled, we try again on the
+// next write.
+func (c *Client) verifyMode(ctx context.Context) error {
+
+	if c.mode == ModeAny {
+		return nil
+	}
+
+	c.mode_mu.Lock()
+	ok := c.mode_ok
+	c.mode_mu.Unlock()
+	if ok {
+		return nil
+	}
+
+	return c.do(ctx, readCall, "GET", c.customerPath("deliveries")+"?filter=", nil, &Deliveries{})
+
+}
+
+// modeMismatch returns the latched mode mismatch, if any
+func (c *Client) modeMismatch() error {
+	c.mode_mu.Lock()
+	defer c.mode_mu.Unlock()
+	if c.mode_err == nil {
+		return nil
+	}
+	return c.mode_err
+}
@@ -0,0 +1,143 @@
+package ghostmates
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClientMode(t *testing.T) {
+
+	requests := 0
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.Write([]byte(`{"kind":"delivery","id":"del_test","live_mode":true}`))
+	}, WithMode(ModeTest))
+
+	if _, err := client.GetDelivery("del_test"); !errors.Is(err, ErrLiveModeMismatch) {
+		t.Fatalf("Expected ErrLiveModeMismatch, got %v", err)
+	}
+
+	// the mismatch is latched, later calls fail without being sent
+	if _, err := client.CancelDelivery("del_test"); !errors.Is(err, ErrLiveModeMismatch) {
+		t.Errorf("Expected ErrLiveModeMismatch, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request, got %d", requests)
+	}
+
+	// every delivery in a listing is checked
+	client = newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"object":"list","data":[{"id":"del_1","live_mode":true},{"id":"del_2","live_mode":false}]}`))
+	}, WithMode(ModeLive))
+
+	if _, err := client.GetDeliveries(AllFilter, AllDeliveries); !errors.Is(err, ErrLiveModeMismatch) || !strings.Contains(err.Error(), "del_2") {
+		t.Errorf("Expected ErrLiveModeMismatch for del_2, got %v", err)
+	}
+
+	if _, err := NewClientWithOptions(TestCustomerId, TestAPIKey, WithMode(Mode(7))); err == nil {
+		t.Errorf("Expected an invalid mode error")
+	}
+
+}
+
+func TestClientModeWrite(t *testing.T) {
+
+	var (
+		requests []string
+		listing  = `{"object":"list","data":[]}`
+	)
+
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method)
+		if req.Method == "GET" {
+			w.Write([]byte(listing))
+			return
+		}
+		w.Write([]byte(`{"kind":"delivery","id":"del_live","live_mode":true}`))
+	}
+
+	client := newTestClient(t, handler, WithMode(ModeTest))
+
+	var (
+		manifest = NewManifest(TestManifestDescription, TestManifestReference)
+		pickup   = NewDeliverySpot(TestPickupName, TestPickupAddress, TestPickupPhoneNumber)
+		dropoff  = NewDeliverySpot(TestDropoffName, TestDropoffAddress, TestDropoffPhoneNumber)
+		quote    = &DeliveryQuote{ID: "dqt_test"}
+	)
+
+	// an empty account can't be checked up front, the live delivery is
+	// created and handed back in the error so it can be cancelled
+	_, err := client.PlaceDelivery(manifest, pickup, dropoff, quote)
+
+	var e *ModeMismatchError
+	if !errors.As(err, &e) || !errors.Is(err, ErrLiveModeMismatch) {
+		t.Fatalf("Expected a *ModeMismatchError, got %v", err)
+	}
+	if e.Delivery == nil || e.Delivery.ID != "del_live" {
+		t.Errorf("Expected the created delivery in the error, got %+v", e.Delivery)
+	}
+	if e.Expected != ModeTest || e.Got != ModeLive {
+		t.Errorf("Expected test/live, got %s/%s", e.Expected, e.Got)
+	}
+	if strings.Join(requests, ",") != "GET,POST" {
+		t.Errorf("Expected a listing before the write, got %v", requests)
+	}
+
+	// the latched error doesn't hand out the delivery again
+	_, err = client.PlaceDelivery(manifest, pickup, dropoff, quote)
+	if !errors.As(err, &e) || e.Delivery != nil {
+		t.Errorf("Expected a latched mismatch without a delivery, got %v", err)
+	}
+
+	// an account with deliveries is refused before anything is written
+	requests, listing = nil, `{"object":"list","data":[{"id":"del_1","live_mode":true}]}`
+	client = newTestClient(t, handler, WithMode(ModeTest))
+
+	if _, err := client.PlaceDelivery(manifest, pickup, dropoff, quote); !errors.Is(err, ErrLiveModeMismatch) {
+		t.Errorf("Expected ErrLiveModeMismatch, got %v", err)
+	}
+	if strings.Join(requests, ",") != "GET" {
+		t.Errorf("Expected only the listing, got %v", requests)
+	}
+
+}
+
+func TestWebhookMode(t *testing.T) {
+
+	var (
+		wh = NewWebhook(WithWebhookMode(ModeLive))
+		s  = httptest.NewServer(wh.Handler)
+	)
+
+	defer s.Close()
+
+	// the recorded payloads are all test mode
+	resp, err := http.Post(s.URL, "application/json", strings.NewReader(TestDeliveryPayloads[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+	if len(wh.Events.DeliveryStatus) != 0 {
+		t.Errorf("Expected the event to be dropped")
+	}
+
+	// a live event carrying a test delivery is rejected too
+	payload := strings.Replace(TestDeliveryPayloads[1], `"live_mode": false`, `"live_mode": true`, 1)
+	resp, err = http.Post(s.URL, "application/json", strings.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+}
@@ -0,0 +1,155 @@
+package ghostmates
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type (
+	// Money is an amount in the minor units of an ISO 4217 currency, eg.
+	// cents for USD.  The zero Money has no currency and can be added to
+	// any other.
+	Money struct {
+		Amount   int64  // Minor units, eg. 799 is $7.99
+		Currency string // Upper case ISO 4217 code, eg. USD
+	}
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+
+	// currencies whose minor unit isn't a hundredth
+	minorDigits = map[string]int{
+		"BHD": 3, "BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "IQD": 3, "ISK": 0,
+		"JOD": 3, "JPY": 0, "KMF": 0, "KRW": 0, "KWD": 3, "LYD": 3, "OMR": 3,
+		"PYG": 0, "RWF": 0, "TND": 3, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+		"XOF": 0, "XPF": 0,
+	}
+)
+
+// NewMoney returns amount minor units of currency, eg. NewMoney(799, "usd").
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// FeeMoney returns the quoted fee with its currency.
+func (dq *DeliveryQuote) FeeMoney() Money {
+	return NewMoney(int64(dq.Fee), dq.Currency)
+}
+
+// FeeMoney returns the delivery fee with its currency.
+func (d *Delivery) FeeMoney() Money {
+	return NewMoney(int64(d.Fee), d.Currency)
+}
+
+// String formats m in major units with its currency code, eg. "7.99 USD".
+func (m Money) String() string {
+
+	digits, ok := minorDigits[m.Currency]
+	if !ok {
+		digits = 2
+	}
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	s := fmt.Sprint(amount)
+	if digits > 0 {
+		if len(s) <= digits {
+			s = strings.Repeat("0", digits-len(s)+1) + s
+		}
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+
+	if len(m.Currency) == 0 {
+		return sign + s
+	}
+
+	return sign + s + " " + m.Currency
+
+}
+
+// IsZero reports whether m is the zero Money.
+func (m Money) IsZero() bool {
+	return m == Money{}
+}
+
+// Add returns m + o, or ErrCurrencyMismatch if they're in different currencies.
+func (m Money) Add(o Money) (Money, error) {
+	currency, err := m.currency(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: currency}, nil
+}
+
+// Sub returns m - o, or ErrCurrencyMismatch if they're in different currencies.
+func (m Money) Sub(o Money) (Money, error) {
+	return m.Add(Money{Amount: -o.Amount, Currency: o.Currency})
+}
+
+// Cmp returns -1, 0 or 1 as m is less than, equal to or greater than o, or
+// ErrCurrencyMismatch if they're in different currencies.
+func (m Money) Cmp(o Money) (int, error) {
+
+	if _, err := m.currency(o); err != nil {
+		return 0, err
+	}
+
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+
+	return 0, nil
+
+}
+
+// currency returns the currency shared by m and o
+func (m Money) currency(o Money) (string, error) {
+	switch {
+	case m.IsZero():
+		return o.Currency, nil
+	case o.IsZero(), m.Currency == o.Currency:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+// UnmarshalJSON decodes an object with a currency and either an amount or,
+// as in quotes and deliveries, a fee.
+func (m *Money) UnmarshalJSON(data []byte) error {
+
+	var v struct {
+		Amount   *int64 `json:"amount"`
+		Fee      *int64 `json:"fee"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*m = NewMoney(0, v.Currency)
+	switch {
+	case v.Amount != nil:
+		m.Amount = *v.Amount
+	case v.Fee != nil:
+		m.Amount = *v.Fee
+	}
+
+	return nil
+
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Amount, m.Currency})
+}
@@ -0,0 +1,76 @@
+package ghostmates
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMoney(t *testing.T) {
+
+	for m, expected := range map[Money]string{
+		NewMoney(799, "usd"):   "7.99 USD",
+		NewMoney(5, "usd"):     "0.05 USD",
+		NewMoney(-1250, "eur"): "-12.50 EUR",
+		NewMoney(500, "jpy"):   "500 JPY",
+		NewMoney(1500, "kwd"):  "1.500 KWD",
+		{}:                     "0.00",
+	} {
+		if m.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, m.String())
+		}
+	}
+
+	var total Money
+	for _, fee := range []Money{NewMoney(799, "usd"), NewMoney(201, "USD")} {
+		var err error
+		if total, err = total.Add(fee); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if total != NewMoney(1000, "usd") {
+		t.Errorf("Expected 10.00 USD, got %s", total)
+	}
+
+	if _, err := total.Add(NewMoney(100, "eur")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+	if _, err := total.Cmp(NewMoney(100, "eur")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Expected ErrCurrencyMismatch, got %v", err)
+	}
+	if c, err := total.Cmp(NewMoney(999, "usd")); err != nil || c != 1 {
+		t.Errorf("Expected 1, got %d %v", c, err)
+	}
+	if d, err := total.Sub(NewMoney(1200, "usd")); err != nil || d.Amount != -200 {
+		t.Errorf("Expected -2.00 USD, got %s %v", d, err)
+	}
+
+}
+
+func TestMoneyJSON(t *testing.T) {
+
+	dq := &DeliveryQuote{}
+	data := []byte(`{"kind":"delivery_quote","id":"dqt_test","fee":799,"currency":"usd"}`)
+	if err := json.Unmarshal(data, dq); err != nil {
+		t.Fatal(err)
+	}
+
+	var m Money
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m != dq.FeeMoney() || m != NewMoney(799, "USD") {
+		t.Errorf("Expected 7.99 USD, got %s and %s", m, dq.FeeMoney())
+	}
+
+	// round trips through its own encoding
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rt Money
+	if err := json.Unmarshal(data, &rt); err != nil || rt != m {
+		t.Errorf("Expected %s, got %s from %s", m, rt, data)
+	}
+
+}
@@ -0,0 +1,76 @@
+package ghostmates
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type (
+	// Option configures a Client created with NewClientWithOptions.
+	Option func(c *Client) error
+)
+
+// WithBaseURL sends requests to base instead of APIBaseURL.  base must be
+// an absolute http(s) url, any path is used as a prefix for api paths.
+func WithBaseURL(base string) Option {
+	return func(c *Client) error {
+		u, err := url.Parse(base)
+		if err != nil {
+			return fmt.Errorf("invalid base url %q: %s", base, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("invalid base url %q: scheme and host required", base)
+		}
+		c.base = u
+		return nil
+	}
+}
+
+// WithTransport sends requests on rt instead of http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) error {
+		if rt == nil {
+			return fmt.Errorf("nil transport")
+		}
+		c.transport = rt
+		return nil
+	}
+}
+
+// WithAPIVersion sets the X-Postmates-Version header sent with every request.
+func WithAPIVersion(version string) Option {
+	return func(c *Client) error {
+		c.version = version
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(user_agent string) Option {
+	return func(c *Client) error {
+		c.user_agent = user_agent
+		return nil
+	}
+}
+
+// WithTimeout bounds every request, including reading the response body.
+// Zero means no timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithClock replaces time.Now as the clock quote expiry is checked against.
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) error {
+		if now == nil {
+			return fmt.Errorf("nil clock")
+		}
+		c.now = now
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package ghostmates
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientOptions(t *testing.T) {
+
+	var req *http.Request
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		req = r
+		w.Write([]byte(`{"kind":"delivery_quote","id":"dqt_test","fee":799,"currency":"usd"}`))
+	}))
+	defer s.Close()
+
+	client, err := NewClientWithOptions("cus_test", "key_test",
+		WithBaseURL(s.URL+"/postmates/"),
+		WithTransport(http.DefaultTransport),
+		WithAPIVersion("20160101"),
+		WithUserAgent("ghostmates-test"),
+		WithTimeout(TestTimeout),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dq, err := client.GetQuote(TestPickupAddress, TestDropoffAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dq.ID != "dqt_test" || dq.Fee != 799 {
+		t.Errorf("Unexpected quote %+v", dq)
+	}
+
+	if req.URL.Path != "/postmates/v1/customers/cus_test/delivery_quotes" {
+		t.Errorf("Expected prefixed path, got %q", req.URL.Path)
+	}
+	if v := req.Header.Get("X-Postmates-Version"); v != "20160101" {
+		t.Errorf("Expected %q, got %q", "20160101", v)
+	}
+	if ua := req.Header.Get("User-Agent"); ua != "ghostmates-test" {
+		t.Errorf("Expected %q, got %q", "ghostmates-test", ua)
+	}
+	if user, _, ok := req.BasicAuth(); !ok || user != "key_test" {
+		t.Errorf("Expected basic auth user %q, got %q", "key_test", user)
+	}
+	if v := req.FormValue("pickup_address"); v != TestPickupAddress {
+		t.Errorf("Expected %q, got %q", TestPickupAddress, v)
+	}
+
+}
+
+func TestClientOptionsInvalid(t *testing.T) {
+
+	for _, base := range []string{"", "api.postmates.com", "ftp://api.postmates.com", "://"} {
+		if _, err := NewClientWithOptions("cus_test", "key_test", WithBaseURL(base)); err == nil {
+			t.Errorf("Expected an error for base url %q", base)
+		}
+	}
+
+	if _, err := NewClientWithOptions("cus_test", "key_test", WithTransport(nil)); err == nil {
+		t.Errorf("Expected an error for nil transport")
+	}
+
+	// the legacy constructor is a wrapper over the defaults plus a timeout
+	client := NewClient("cus_test", "key_test", time.Second)
+	if client.base.String() != APIBaseURL || client.client.Timeout != time.Second || client.version != APIVersion {
+		t.Errorf("Unexpected defaults %+v", client)
+	}
+
+}
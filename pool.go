@@ -0,0 +1,202 @@
+package ghostmates
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+)
+
+type (
+	// Credentials identify and authenticate a postmates account.
+	Credentials struct {
+		CustomerID string
+		APIKey     string
+	}
+
+	// TenantLookup returns the postmates credentials for a tenant.
+	TenantLookup func(ctx context.Context, tenant string) (Credentials, error)
+
+	// ClientPool lazily creates and caches one Client per tenant.  Clients
+	// share a connection pool, and clients on the same postmates account
+	// share a rate limit budget.  Past MaxClients tenants the least recently
+	// used client is dropped.
+	ClientPool struct {
+		lookup      TenantLookup
+		opts        []Option
+		per_second  float64
+		burst       int
+		max_clients int
+
+		mu       sync.Mutex
+		clients  map[string]*list.Element // by tenant, values are *pooledClient
+		lru      *list.List               // most recently used first
+		limiters map[string]*RateLimiter  // by customer id, only accounts with a cached client
+	}
+
+	pooledClient struct {
+		tenant string
+		client *Client
+		creds  Credentials
+	}
+)
+
+const (
+	DefaultMaxPoolClients = 1000
+)
+
+// NewClientPool returns a pool that creates clients with opts for the
+// credentials lookup returns.  Each account is limited to per_second requests
+// on average with bursts of up to burst, a per_second of zero or less
+// disables limiting.
+func NewClientPool(lookup TenantLookup, per_second float64, burst int, opts ...Option) *ClientPool {
+
+	// one connection pool for every tenant, separate from http.DefaultTransport.
+	// DefaultTransport may have been wrapped, eg. by tracing libraries, in
+	// which case we use it as is rather than lose the wrapper.
+	var transport http.RoundTripper = http.DefaultTransport
+	if t, ok := transport.(*http.Transport); ok {
+		transport = t.Clone()
+	}
+
+	return &ClientPool{
+		lookup:      lookup,
+		opts:        append([]Option{WithTransport(transport)}, opts...),
+		per_second:  per_second,
+		burst:       burst,
+		max_clients: DefaultMaxPoolClients,
+		clients:     map[string]*list.Element{},
+		lru:         list.New(),
+		limiters:    map[string]*RateLimiter{},
+	}
+
+}
+
+// SetMaxClients caps the number of cached clients at n, DefaultMaxPoolClients
+// by default, evicting the least recently used beyond it.  Zero or less
+// removes the cap.
+func (p *ClientPool) SetMaxClients(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.max_clients = n
+	p.evict()
+}
+
+// Len returns the number of cached clients.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lru.Len()
+}
+
+// Get returns the client for tenant, creating it on first use.
+func (p *ClientPool) Get(ctx context.Context, tenant string) (*Client, error) {
+
+	var c *Client
+
+	p.mu.Lock()
+	if e, ok := p.clients[tenant]; ok {
+		p.lru.MoveToFront(e)
+		c = e.Value.(*pooledClient).client
+	}
+	p.mu.Unlock()
+
+	if c != nil {
+		return c, nil
+	}
+
+	return p.Reload(ctx, tenant)
+
+}
+
+// Reload looks up tenant's credentials again and replaces its client if they
+// changed.  Clients already handed out keep working with the old credentials.
+func (p *ClientPool) Reload(ctx context.Context, tenant string) (*Client, error) {
+
+	// lookups may be slow, don't hold up other tenants
+	creds, err := p.lookup(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, cached := p.clients[tenant]
+	if cached && e.Value.(*pooledClient).creds == creds {
+		p.lru.MoveToFront(e)
+		return e.Value.(*pooledClient).client, nil
+	}
+
+	limiter, ok := p.limiters[creds.CustomerID]
+	if !ok {
+		limiter = NewRateLimiter(p.per_second, p.burst)
+		p.limiters[creds.CustomerID] = limiter
+	}
+
+	opts := append(append([]Option{}, p.opts...), WithRateLimiter(limiter))
+
+	c, err := NewClientWithOptions(creds.CustomerID, creds.APIKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	pc := &pooledClient{tenant: tenant, client: c, creds: creds}
+	if cached {
+		e.Value = pc
+		p.lru.MoveToFront(e)
+	} else {
+		p.clients[tenant] = p.lru.PushFront(pc)
+		p.evict()
+	}
+
+	return c, nil
+
+}
+
+// Invalidate drops tenant's cached client so the next Get looks it up again.
+func (p *ClientPool) Invalidate(tenant string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.clients[tenant]; ok {
+		p.remove(e)
+	}
+}
+
+// InvalidateAll drops every cached client.  Rate limit budgets are kept.
+func (p *ClientPool) InvalidateAll() {
+	p.mu.Lock()
+	p.clients = map[string]*list.Element{}
+	p.lru.Init()
+	p.mu.Unlock()
+}
+
+// evict drops least recently used clients beyond max_clients, and the rate
+// limiters of accounts left without a cached client.  Clients already handed
+// out keep their limiter.  p.mu must be held.
+func (p *ClientPool) evict() {
+
+	if p.max_clients <= 0 || p.lru.Len() <= p.max_clients {
+		return
+	}
+
+	for p.lru.Len() > p.max_clients {
+		p.remove(p.lru.Back())
+	}
+
+	used := map[string]bool{}
+	for e := p.lru.Front(); e != nil; e = e.Next() {
+		used[e.Value.(*pooledClient).creds.CustomerID] = true
+	}
+	for id := range p.limiters {
+		if !used[id] {
+			delete(p.limiters, id)
+		}
+	}
+
+}
+
+// remove drops a cached client, p.mu must be held
+func (p *ClientPool) remove(e *list.Element) {
+	delete(p.clients, p.lru.Remove(e).(*pooledClient).tenant)
+}
@@ -0,0 +1,139 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientPool(t *testing.T) {
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"kind":"delivery","id":"del_test"}`))
+	}))
+	t.Cleanup(s.Close)
+
+	var (
+		lookups int
+		creds   = map[string]Credentials{
+			"acme":    {CustomerID: "cus_acme", APIKey: "key_1"},
+			"globex":  {CustomerID: "cus_globex", APIKey: "key_2"},
+			"initech": {CustomerID: "cus_acme", APIKey: "key_1"}, // same account as acme
+		}
+		lookup = func(ctx context.Context, tenant string) (Credentials, error) {
+			lookups++
+			c, ok := creds[tenant]
+			if !ok {
+				return Credentials{}, errors.New("unknown tenant")
+			}
+			return c, nil
+		}
+	)
+
+	pool := NewClientPool(lookup, 1, 1, WithBaseURL(s.URL))
+
+	acme, err := pool.Get(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := pool.Get(context.Background(), "acme"); again != acme || lookups != 1 {
+		t.Errorf("Expected the cached client after 1 lookup, got %d lookups", lookups)
+	}
+
+	globex, _ := pool.Get(context.Background(), "globex")
+	initech, _ := pool.Get(context.Background(), "initech")
+	if acme.transport != globex.transport {
+		t.Errorf("Expected clients to share a transport")
+	}
+	if acme.read_limiter != initech.read_limiter || acme.read_limiter == globex.read_limiter {
+		t.Errorf("Expected clients to share a rate limiter per account")
+	}
+
+	if _, err := pool.Get(context.Background(), "hooli"); err == nil {
+		t.Errorf("Expected a lookup error")
+	}
+
+	// unchanged credentials keep the client, changed ones replace it
+	if c, _ := pool.Reload(context.Background(), "acme"); c != acme {
+		t.Errorf("Expected reload with unchanged credentials to keep the client")
+	}
+	creds["acme"] = Credentials{CustomerID: "cus_acme", APIKey: "key_3"}
+	c, err := pool.Reload(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == acme || c.credentials != StaticCredentials("key_3") || c.read_limiter != acme.read_limiter {
+		t.Errorf("Expected a new client on the same account budget")
+	}
+
+	pool.Invalidate("globex")
+	lookups = 0
+	if c, _ := pool.Get(context.Background(), "globex"); c == globex || lookups != 1 {
+		t.Errorf("Expected invalidate to force a lookup")
+	}
+
+	if _, err := c.GetDelivery("del_test"); err != nil {
+		t.Error(err)
+	}
+
+}
+
+func TestClientPoolEviction(t *testing.T) {
+
+	pool := NewClientPool(func(ctx context.Context, tenant string) (Credentials, error) {
+		return Credentials{CustomerID: "cus_" + tenant, APIKey: "key"}, nil
+	}, 1, 1)
+	pool.SetMaxClients(2)
+
+	acme, _ := pool.Get(context.Background(), "acme")
+	pool.Get(context.Background(), "globex")
+
+	// acme is used again so globex is the least recently used
+	pool.Get(context.Background(), "acme")
+	pool.Get(context.Background(), "initech")
+
+	if pool.Len() != 2 {
+		t.Errorf("Expected 2 cached clients, got %d", pool.Len())
+	}
+	if c, _ := pool.Get(context.Background(), "acme"); c != acme {
+		t.Errorf("Expected acme to stay cached")
+	}
+	if _, ok := pool.limiters["cus_globex"]; ok || len(pool.limiters) != 2 {
+		t.Errorf("Expected the evicted account's limiter to be dropped, got %v", pool.limiters)
+	}
+
+	pool.SetMaxClients(1)
+	if pool.Len() != 1 {
+		t.Errorf("Expected 1 cached client, got %d", pool.Len())
+	}
+
+}
+
+func TestClientPoolWrappedDefaultTransport(t *testing.T) {
+
+	// eg. an instrumentation library wrapping the default transport
+	defer func(rt http.RoundTripper) { http.DefaultTransport = rt }(http.DefaultTransport)
+
+	wrapped := &tracingTransport{http.DefaultTransport}
+	http.DefaultTransport = wrapped
+
+	pool := NewClientPool(func(ctx context.Context, tenant string) (Credentials, error) {
+		return Credentials{CustomerID: "cus_" + tenant, APIKey: "key"}, nil
+	}, 0, 0)
+
+	c, err := pool.Get(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the wrapper, and the transport settings under it, are kept
+	if c.transport != wrapped {
+		t.Errorf("Expected the wrapped default transport, got %T", c.transport)
+	}
+
+}
+
+type tracingTransport struct {
+	http.RoundTripper
+}
@@ -0,0 +1,249 @@
+package ghostmates
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	// Proof is a signature or photo collected at dropoff.  ContentType and
+	// Size are filled in once it is downloaded.
+	Proof struct {
+		DeliveryID  string
+		Kind        string // ProofSignature or ProofPhoto
+		Href        string
+		ReceivedBy  string // Delivery.DropoffIdentifier, who received the delivery
+		ContentType string
+		Size        int64
+	}
+
+	// ProofStore keeps downloaded proof, eg. on disk or in a blob store.
+	ProofStore interface {
+		Put(ctx context.Context, p *Proof, r io.Reader) error
+	}
+
+	// ProofStoreFunc adapts a function to a ProofStore, eg. to write each
+	// proof to a writer of its own.
+	ProofStoreFunc func(ctx context.Context, p *Proof, r io.Reader) error
+
+	// DirProofStore stores proof as files under a directory, one
+	// subdirectory per delivery.
+	DirProofStore string
+)
+
+const (
+	ProofSignature = "signature"
+	ProofPhoto     = "photo"
+
+	MaxProofSize = 10 << 20 // 10mb is plenty for a signature or photo
+)
+
+var (
+	ErrNoProof       = errors.New("delivery has no proof of delivery")
+	ErrProofTooLarge = errors.New("proof of delivery too large")
+)
+
+// Proofs lists the proof available for d, the signature first.
+func (d *Delivery) Proofs() []*Proof {
+
+	var (
+		ps        []*Proof
+		signature = d.CustomerSignatureImgHref
+		photo     string
+	)
+
+	if v := d.Dropoff.Verification; v != nil {
+		if v.Signature != nil && len(signature) == 0 {
+			signature = v.Signature.ImageURL
+		}
+		if v.Picture != nil {
+			photo = v.Picture.ImageURL
+		}
+	}
+
+	for _, p := range []struct{ kind, href string }{{ProofSignature, signature}, {ProofPhoto, photo}} {
+		if len(p.href) > 0 {
+			ps = append(ps, &Proof{DeliveryID: d.ID, Kind: p.kind, Href: p.href, ReceivedBy: d.DropoffIdentifier})
+		}
+	}
+
+	return ps
+
+}
+
+// DownloadProofOfDelivery downloads d's signature and photo, whichever it
+// has, into store and returns them in the order stored.  It returns ErrNoProof
+// if there is neither.  On error the proofs stored so far are returned.
+func (c *Client) DownloadProofOfDelivery(ctx context.Context, d *Delivery, store ProofStore) ([]*Proof, error) {
+
+	ps := d.Proofs()
+	if len(ps) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoProof, d.ID)
+	}
+
+	var buf bytes.Buffer
+
+	for i, p := range ps {
+		buf.Reset()
+		if err := c.DownloadProof(ctx, p, &buf); err != nil {
+			return ps[:i], err
+		}
+		if err := store.Put(ctx, p, &buf); err != nil {
+			return ps[:i], err
+		}
+	}
+
+	return ps, nil
+
+}
+
+// DownloadProof writes the image p refers to to w, recording its content
+// type and size on p.  Images are hosted outside the api, so the request is
+// sent without credentials or middleware.  The image is read in full before
+// anything is written, so w is untouched if the download fails or exceeds
+// MaxProofSize.
+func (c *Client) DownloadProof(ctx context.Context, p *Proof, w io.Writer) error {
+
+	u, err := url.Parse(p.Href)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("invalid proof href %q", p.Href)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Transport: c.transport, Timeout: c.timeout}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return &TransportError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to download %s for %s: %s", p.Kind, p.DeliveryID, resp.Status)
+	}
+
+	too_large := fmt.Errorf("%w: %s for %s exceeds %d bytes", ErrProofTooLarge, p.Kind, p.DeliveryID, MaxProofSize)
+	if resp.ContentLength > MaxProofSize {
+		return too_large
+	}
+
+	var buf bytes.Buffer
+	if resp.ContentLength > 0 {
+		buf.Grow(int(resp.ContentLength))
+	}
+
+	n, err := buf.ReadFrom(io.LimitReader(resp.Body, MaxProofSize+1))
+	if err != nil {
+		return &TransportError{Err: err}
+	}
+	if n > MaxProofSize {
+		return too_large
+	}
+
+	if _, err := buf.WriteTo(w); err != nil {
+		return err
+	}
+
+	p.ContentType, p.Size = resp.Header.Get("Content-Type"), n
+
+	return nil
+
+}
+
+// ArchiveProofOfDelivery downloads the proof of every complete delivery
+// matching opts into store, returning how many were stored.  A nil opts
+// archives all deliveries.
+func (c *Client) ArchiveProofOfDelivery(ctx context.Context, store ProofStore, opts *ListOptions) (int, error) {
+
+	var (
+		n  int
+		it = c.IterDeliveriesWithOptions(ctx, opts)
+	)
+
+	for it.Next() {
+
+		d := it.Delivery()
+		if d.Status != StatusDelivered || len(d.Proofs()) == 0 {
+			continue
+		}
+
+		ps, err := c.DownloadProofOfDelivery(ctx, d, store)
+		n += len(ps)
+		if err != nil {
+			return n, err
+		}
+
+	}
+
+	return n, it.Err()
+
+}
+
+func (f ProofStoreFunc) Put(ctx context.Context, p *Proof, r io.Reader) error {
+	return f(ctx, p, r)
+}
+
+// Put writes p to <dir>/<delivery id>/<kind><ext>, replacing any earlier copy.
+func (dir DirProofStore) Put(ctx context.Context, p *Proof, r io.Reader) error {
+
+	// ids come from the api, keep them from escaping the directory
+	if len(p.DeliveryID) == 0 || strings.ContainsAny(p.DeliveryID, `/\`) || strings.HasPrefix(p.DeliveryID, ".") {
+		return fmt.Errorf("invalid delivery id %q", p.DeliveryID)
+	}
+
+	path := filepath.Join(string(dir), p.DeliveryID)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	path = filepath.Join(path, p.Kind+proofExt(p.ContentType))
+
+	// write to a temp file first so a failed download doesn't clobber a good copy
+	f, err := os.CreateTemp(filepath.Dir(path), ".proof-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+
+}
+
+// proofExt picks a file extension for a content type
+func proofExt(content_type string) string {
+
+	t, _, _ := mime.ParseMediaType(content_type)
+	switch t {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	}
+
+	if exts, _ := mime.ExtensionsByType(t); len(exts) > 0 {
+		return exts[0]
+	}
+
+	return ".bin"
+
+}
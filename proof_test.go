@@ -0,0 +1,126 @@
+package ghostmates
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProofOfDelivery(t *testing.T) {
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+
+		if strings.HasPrefix(req.URL.Path, "/img/") {
+			if _, _, ok := req.BasicAuth(); ok {
+				t.Errorf("Expected proof to be fetched without credentials")
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("png:" + req.URL.Path))
+			return
+		}
+
+		base := "http://" + req.Host + "/img/"
+		fmt.Fprintf(w, `{"object":"list","data":[
+			{"id":"del_1","status":"delivered","dropoff_identifier":"Doorman","customer_signature_img_href":%q,"dropoff":{"verification":{"picture":{"image_url":%q}}}},
+			{"id":"del_2","status":"dropoff","customer_signature_img_href":%q},
+			{"id":"del_3","status":"delivered"}
+		]}`, base+"sig1", base+"photo1", base+"sig2")
+
+	})
+
+	ds, err := client.GetDeliveries(AllFilter, AllDeliveries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		got   = map[string]string{}
+		store = ProofStoreFunc(func(ctx context.Context, p *Proof, r io.Reader) error {
+			data, err := io.ReadAll(r)
+			got[p.Kind] = string(data)
+			return err
+		})
+	)
+
+	ps, err := client.DownloadProofOfDelivery(context.Background(), ds[0], store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 2 || ps[0].Kind != ProofSignature || ps[1].Kind != ProofPhoto {
+		t.Fatalf("Expected the signature and photo, got %v", ps)
+	}
+	if p := ps[0]; p.ContentType != "image/png" || p.Size != int64(len(got[ProofSignature])) || p.ReceivedBy != "Doorman" {
+		t.Errorf("Unexpected proof %+v", p)
+	}
+	if got[ProofSignature] != "png:/img/sig1" || got[ProofPhoto] != "png:/img/photo1" {
+		t.Errorf("Unexpected proof images %q", got)
+	}
+
+	if _, err := client.DownloadProofOfDelivery(context.Background(), ds[2], store); !errors.Is(err, ErrNoProof) {
+		t.Errorf("Expected ErrNoProof, got %v", err)
+	}
+
+	// only delivered deliveries are archived
+	dir := t.TempDir()
+	n, err := client.ArchiveProofOfDelivery(context.Background(), DirProofStore(dir), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 proofs archived, got %d", n)
+	}
+	for name, expected := range map[string]string{
+		"del_1/signature.png": "png:/img/sig1",
+		"del_1/photo.png":     "png:/img/photo1",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(data) != expected {
+			t.Errorf("%s: expected %q, got %q %v", name, expected, data, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "del_2")); !os.IsNotExist(err) {
+		t.Errorf("Expected del_2 not to be archived")
+	}
+
+	if err := DirProofStore(dir).Put(context.Background(), &Proof{DeliveryID: "../evil", Kind: ProofPhoto}, strings.NewReader("")); err == nil {
+		t.Errorf("Expected an invalid delivery id error")
+	}
+
+}
+
+func TestDownloadProofTooLarge(t *testing.T) {
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		// a declared length is refused up front, an undeclared one once read
+		if req.URL.Path == "/declared" {
+			w.Header().Set("Content-Length", fmt.Sprint(MaxProofSize+1))
+			return
+		}
+		w.(http.Flusher).Flush()
+		w.Write(make([]byte, MaxProofSize+1))
+	})
+
+	for _, path := range []string{"/declared", "/chunked"} {
+
+		var (
+			buf bytes.Buffer
+			p   = &Proof{DeliveryID: "del_1", Kind: ProofPhoto, Href: client.base.String() + path}
+		)
+
+		if err := client.DownloadProof(context.Background(), p, &buf); !errors.Is(err, ErrProofTooLarge) {
+			t.Errorf("%s: expected ErrProofTooLarge, got %v", path, err)
+		}
+		if buf.Len() != 0 || p.Size != 0 {
+			t.Errorf("%s: expected nothing written, got %d bytes", path, buf.Len())
+		}
+
+	}
+
+}
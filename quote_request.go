@@ -0,0 +1,109 @@
+package ghostmates
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+type (
+	// QuoteRequest holds the full set of delivery quote parameters.
+	// Only set fields are sent.
+	QuoteRequest struct {
+		PickupAddress  string
+		DropoffAddress string
+
+		PickupLocation  *Location // Lat/lng hint used to disambiguate the pickup address
+		DropoffLocation *Location // Lat/lng hint used to disambiguate the dropoff address
+
+		PickupPhoneNumber  string
+		DropoffPhoneNumber string
+
+		PickupReady     *time.Time // Earliest time the items will be ready for pickup
+		PickupDeadline  *time.Time // Latest time the items may be picked up
+		DropoffReady    *time.Time // Earliest time the items may be dropped off
+		DropoffDeadline *time.Time // Latest time the items may be dropped off
+	}
+)
+
+// NewQuoteRequest returns a request for quoting between two addresses.
+// Locations, phone numbers and time windows narrow the quote further.
+func NewQuoteRequest(pickup_address, dropoff_address string) *QuoteRequest {
+	return &QuoteRequest{
+		PickupAddress:  pickup_address,
+		DropoffAddress: dropoff_address,
+	}
+}
+
+// Validate checks the request the way the api would, returning a
+// *ValidationError naming the first offending field.
+func (qr *QuoteRequest) Validate() error {
+
+	if len(qr.PickupAddress) == 0 {
+		return &ValidationError{Field: "PickupAddress", Message: "is required"}
+	}
+	if len(qr.DropoffAddress) == 0 {
+		return &ValidationError{Field: "DropoffAddress", Message: "is required"}
+	}
+
+	if err := validateLocation("PickupLocation", qr.PickupLocation); err != nil {
+		return err
+	}
+	if err := validateLocation("DropoffLocation", qr.DropoffLocation); err != nil {
+		return err
+	}
+
+	if err := validateWindow("PickupDeadline", qr.PickupReady, qr.PickupDeadline); err != nil {
+		return err
+	}
+	if err := validateWindow("DropoffDeadline", qr.DropoffReady, qr.DropoffDeadline); err != nil {
+		return err
+	}
+
+	return validateWindow("DropoffDeadline", qr.PickupReady, qr.DropoffDeadline)
+
+}
+
+// Values form encodes the set fields of the request.
+func (qr *QuoteRequest) Values() url.Values {
+
+	v := form{}
+
+	v.set("pickup_address", qr.PickupAddress)
+	v.setLocation("pickup", qr.PickupLocation)
+	v.set("pickup_phone_number", qr.PickupPhoneNumber)
+	v.setTime("pickup_ready_dt", qr.PickupReady)
+	v.setTime("pickup_deadline_dt", qr.PickupDeadline)
+
+	v.set("dropoff_address", qr.DropoffAddress)
+	v.setLocation("dropoff", qr.DropoffLocation)
+	v.set("dropoff_phone_number", qr.DropoffPhoneNumber)
+	v.setTime("dropoff_ready_dt", qr.DropoffReady)
+	v.setTime("dropoff_deadline_dt", qr.DropoffDeadline)
+
+	return url.Values(v)
+
+}
+
+// GetQuoteRequest validates qr locally and quotes the delivery it describes.
+func (c *Client) GetQuoteRequest(qr *QuoteRequest) (*DeliveryQuote, error) {
+	return c.GetQuoteRequestContext(context.Background(), qr)
+}
+
+func (c *Client) GetQuoteRequestContext(ctx context.Context, qr *QuoteRequest) (*DeliveryQuote, error) {
+
+	// POST /v1/customers/:customer_id/delivery_quotes
+	// Returns: DeliveryQuote Object
+
+	if err := qr.Validate(); err != nil {
+		return nil, err
+	}
+
+	dq := &DeliveryQuote{}
+	if err := c.do(ctx, readCall, "POST", c.customerPath("delivery_quotes"), qr.Values(), dq); err != nil {
+		return nil, withParamFields(err, quoteParamFields)
+	}
+
+	return dq, nil
+
+}
@@ -0,0 +1,82 @@
+package ghostmates
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestGetQuoteRequest(t *testing.T) {
+
+	var form url.Values
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		form = req.PostForm
+		w.Write([]byte(`{"kind":"delivery_quote","id":"dqt_test","fee":799,"currency":"usd","duration":60}`))
+	})
+
+	var (
+		ready    = time.Date(2015, 5, 24, 19, 0, 0, 0, time.UTC)
+		deadline = ready.Add(2 * time.Hour)
+		qr       = NewQuoteRequest(TestPickupAddress, TestDropoffAddress)
+	)
+
+	qr.PickupLocation = &Location{Lat: TestPickupLat, Lng: TestPickupLng}
+	qr.DropoffLocation = &Location{Lat: TestDropoffLat, Lng: TestDropoffLng}
+	qr.PickupPhoneNumber = TestPickupPhoneNumber
+	qr.PickupReady = &ready
+	qr.DropoffDeadline = &deadline
+
+	dq, err := client.GetQuoteRequest(qr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dq.ID != "dqt_test" || dq.Fee != 799 {
+		t.Errorf("Unexpected quote %+v", dq)
+	}
+
+	for key, value := range map[string]string{
+		"pickup_address":      TestPickupAddress,
+		"pickup_latitude":     "40.74527",
+		"pickup_longitude":    "-74.007889",
+		"pickup_phone_number": TestPickupPhoneNumber,
+		"pickup_ready_dt":     "2015-05-24T19:00:00Z",
+		"dropoff_address":     TestDropoffAddress,
+		"dropoff_latitude":    "40.75626",
+		"dropoff_longitude":   "-73.990501",
+		"dropoff_deadline_dt": "2015-05-24T21:00:00Z",
+	} {
+		if form.Get(key) != value {
+			t.Errorf("%s: expected %q, got %q", key, value, form.Get(key))
+		}
+	}
+	for _, key := range []string{"dropoff_phone_number", "pickup_deadline_dt", "dropoff_ready_dt"} {
+		if _, ok := form[key]; ok {
+			t.Errorf("Expected %s to be omitted", key)
+		}
+	}
+
+}
+
+func TestQuoteRequestValidate(t *testing.T) {
+
+	var (
+		ready  = time.Now()
+		before = ready.Add(-time.Minute)
+	)
+
+	for field, qr := range map[string]*QuoteRequest{
+		"PickupAddress":      {DropoffAddress: TestDropoffAddress},
+		"DropoffAddress":     {PickupAddress: TestPickupAddress},
+		"PickupLocation.Lng": {PickupAddress: TestPickupAddress, DropoffAddress: TestDropoffAddress, PickupLocation: &Location{Lng: 200}},
+		"DropoffDeadline":    {PickupAddress: TestPickupAddress, DropoffAddress: TestDropoffAddress, DropoffReady: &ready, DropoffDeadline: &before},
+	} {
+		err := qr.Validate()
+		if e, ok := err.(*ValidationError); !ok || e.Field != field {
+			t.Errorf("Expected a validation error on %s, got %v", field, err)
+		}
+	}
+
+}
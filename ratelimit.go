@@ -0,0 +1,103 @@
+package ghostmates
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// RateLimiter is a token bucket shared by every goroutine, and every
+	// Client, it is handed to.  A nil *RateLimiter never blocks.
+	RateLimiter struct {
+		mu     sync.Mutex
+		rate   float64 // tokens added per second
+		burst  float64 // bucket capacity
+		tokens float64 // may go negative while callers are waiting on reservations
+		last   time.Time
+	}
+)
+
+// NewRateLimiter allows per_second requests on average with bursts of up to
+// burst requests.  A per_second of zero or less disables limiting.
+func NewRateLimiter(per_second float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   per_second,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// WithRateLimit limits all requests made by the client to per_second on
+// average with bursts of up to burst.
+func WithRateLimit(per_second float64, burst int) Option {
+	return WithRateLimiter(NewRateLimiter(per_second, burst))
+}
+
+// WithRateLimiter limits all requests made by the client with l, which may
+// be shared with other clients on the same account.
+func WithRateLimiter(l *RateLimiter) Option {
+	return WithReadWriteRateLimiters(l, l)
+}
+
+// WithReadWriteRateLimiters gives reads (quotes and delivery lookups) and
+// writes (creating, canceling and returning deliveries) separate budgets.
+// Either may be nil to leave that side unlimited.
+func WithReadWriteRateLimiters(read, write *RateLimiter) Option {
+	return func(c *Client) error {
+		c.read_limiter = read
+		c.write_limiter = write
+		return nil
+	}
+}
+
+// Wait blocks until a request may be made or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+
+	if l == nil || l.rate <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	// reserve a token now, callers queue up behind each other in debt
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		// hand the reservation back for the next caller
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+
+}
+
+func (c *Client) limiter(kind callKind) *RateLimiter {
+	if kind == writeCall {
+		return c.write_limiter
+	}
+	return c.read_limiter
+}
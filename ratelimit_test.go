@@ -0,0 +1,95 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+
+	var (
+		l     = NewRateLimiter(100, 5)
+		wg    sync.WaitGroup
+		start = time.Now()
+	)
+
+	// 5 burst + 10 at 100/s should take ~100ms
+	for i := 0; i < 15; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected limiter to hold callers for ~100ms, took %s", elapsed)
+	}
+
+}
+
+func TestRateLimiterContext(t *testing.T) {
+
+	l := NewRateLimiter(1, 1)
+
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// a nil limiter never blocks
+	var none *RateLimiter
+	if err := none.Wait(context.Background()); err != nil {
+		t.Error(err)
+	}
+
+}
+
+func TestClientReadWriteRateLimiters(t *testing.T) {
+
+	var (
+		requests int32
+		write    = NewRateLimiter(0.001, 1)
+	)
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"kind":"delivery","id":"del_test"}`))
+	}, WithReadWriteRateLimiters(nil, write))
+
+	// spend the only write token
+	if _, err := client.CancelDelivery("del_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.ReturnDeliveryContext(ctx, "del_test"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// reads have their own (unlimited) budget
+	if _, err := client.GetDelivery("del_test"); err != nil {
+		t.Error(err)
+	}
+
+	if requests != 2 {
+		t.Errorf("Expected 2 requests to reach the server, got %d", requests)
+	}
+
+}
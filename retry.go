@@ -0,0 +1,147 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+type (
+	// RetryPolicy controls how the client resends requests that failed for
+	// transient reasons: service_unavailable and request_rate_limit_exceeded
+	// errors, 5xx responses and reset connections.
+	//
+	// Calls that create or change a delivery (CreateDelivery, CancelDelivery,
+	// ReturnDelivery) are only resent when the api refused them outright, ie.
+	// rate limited or refused connections, unless RetryNonIdempotent is set.
+	//
+	// When retries are enabled a zero MinBackoff or MaxBackoff takes the
+	// DefaultRetryPolicy value, so RetryPolicy{MaxAttempts: 5} still backs off.
+	RetryPolicy struct {
+		MaxAttempts        int           // total attempts including the first, 1 or less disables retries
+		MinBackoff         time.Duration // delay before the first retry, doubled for each one after, DefaultRetryPolicy.MinBackoff if zero
+		MaxBackoff         time.Duration // cap on any single delay, a longer Retry-After gives up instead, DefaultRetryPolicy.MaxBackoff if zero
+		RetryNonIdempotent bool          // resend writes even when the api may have acted on the failed attempt
+	}
+
+	// callKind describes the side effects of an api call
+	callKind int
+)
+
+const (
+	readCall  callKind = iota // no side effects, always safe to resend
+	writeCall                 // creates or changes a delivery
+)
+
+var (
+	DefaultRetryPolicy = RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  250 * time.Millisecond,
+		MaxBackoff:  10 * time.Second,
+	}
+
+	NoRetries = RetryPolicy{MaxAttempts: 1}
+)
+
+// WithRetryPolicy replaces DefaultRetryPolicy, the default for clients from
+// NewClientWithOptions.  Pass NoRetries to disable retries.  Clients from
+// NewClient don't retry.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) error {
+		if policy.MaxAttempts > 1 {
+			if policy.MinBackoff <= 0 {
+				policy.MinBackoff = DefaultRetryPolicy.MinBackoff
+			}
+			if policy.MaxBackoff <= 0 {
+				policy.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+			}
+		}
+		c.retry = policy
+		return nil
+	}
+}
+
+func (p RetryPolicy) retryable(kind callKind, err error) bool {
+
+	// the caller gave up, nothing to retry
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// safe to resend anything the api refused before acting on it
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Code == ErrorCodeRequestRateLimitExceeded || e.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+	} else if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	if kind == writeCall && !p.RetryNonIdempotent {
+		return false
+	}
+
+	if e != nil {
+		return e.Code == ErrorCodeServiceUnavailable || e.StatusCode >= 500
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+
+}
+
+// backoff returns the delay before the retry following attempt n, and false
+// if the server asked us to wait longer than MaxBackoff.
+func (p RetryPolicy) backoff(n int, retry_after time.Duration) (time.Duration, bool) {
+
+	if p.MaxBackoff > 0 && retry_after > p.MaxBackoff {
+		return 0, false
+	}
+
+	d := p.MinBackoff
+	for i := 1; i < n && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	// jitter across the upper half so concurrent callers spread out
+	if half := int64(d / 2); half > 0 {
+		d = time.Duration(half + rand.Int63n(half+1))
+	}
+
+	if d < retry_after {
+		d = retry_after
+	}
+
+	return d, true
+
+}
+
+// parseRetryAfter reads a Retry-After header in either delay-seconds or http-date form
+func parseRetryAfter(v string, now time.Time) time.Duration {
+
+	if len(v) == 0 {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+
+}
@@ -0,0 +1,175 @@
+package ghostmates
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var TestRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  time.Millisecond,
+	MaxBackoff:  50 * time.Millisecond,
+}
+
+func TestRetryRead(t *testing.T) {
+
+	var attempts int32
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"kind":"error","code":"service_unavailable","message":"try again"}`))
+			return
+		}
+		w.Write([]byte(`{"kind":"delivery","id":"del_test"}`))
+	}, WithRetryPolicy(TestRetryPolicy))
+
+	d, err := client.GetDelivery("del_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.ID != "del_test" {
+		t.Errorf("Expected %q, got %q", "del_test", d.ID)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+
+}
+
+func TestRetryWrite(t *testing.T) {
+
+	var attempts int32
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"kind":"error","code":"service_unavailable","message":"try again"}`))
+	}, WithRetryPolicy(TestRetryPolicy))
+
+	// a cancel may have been applied before the 503, never resend it
+	_, err := client.CancelDelivery("del_test")
+	if e, ok := err.(*Error); !ok || e.Code != ErrorCodeServiceUnavailable {
+		t.Errorf("Expected a service_unavailable error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+
+}
+
+func TestRetryRateLimited(t *testing.T) {
+
+	var attempts int32
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"kind":"error","code":"request_rate_limit_exceeded","message":"slow down"}`))
+			return
+		}
+		w.Write([]byte(`{"kind":"delivery","id":"del_test","status":"canceled"}`))
+	}, WithRetryPolicy(TestRetryPolicy))
+
+	// rate limited writes were refused outright so they are safe to resend
+	if _, err := client.CancelDelivery("del_test"); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+
+}
+
+func TestRetryAfterTooLong(t *testing.T) {
+
+	var attempts int32
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"kind":"error","code":"request_rate_limit_exceeded","message":"slow down"}`))
+	}, WithRetryPolicy(TestRetryPolicy))
+
+	_, err := client.GetDelivery("del_test")
+	if e, ok := err.(*Error); !ok || e.RetryAfter != time.Hour {
+		t.Errorf("Expected an error with a 1h RetryAfter, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+
+}
+
+func TestRetryContextCanceled(t *testing.T) {
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}, WithRetryPolicy(RetryPolicy{MaxAttempts: 10, MinBackoff: time.Hour, MaxBackoff: time.Hour}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := client.GetDeliveryContext(ctx, "del_test"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+}
+
+func TestParseRetryAfter(t *testing.T) {
+
+	now := time.Date(2015, 5, 24, 19, 47, 17, 0, time.UTC)
+
+	for v, expected := range map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-1":                            0,
+		"junk":                          0,
+		"Sun, 24 May 2015 19:47:47 GMT": 30 * time.Second,
+		"Sun, 24 May 2015 19:47:00 GMT": 0,
+	} {
+		if d := parseRetryAfter(v, now); d != expected {
+			t.Errorf("%q: expected %s, got %s", v, expected, d)
+		}
+	}
+
+}
+
+func TestRetryPolicyDefaults(t *testing.T) {
+
+	client, err := NewClientWithOptions(TestCustomerId, TestAPIKey, WithRetryPolicy(RetryPolicy{MaxAttempts: 5}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.retry.MinBackoff != DefaultRetryPolicy.MinBackoff || client.retry.MaxBackoff != DefaultRetryPolicy.MaxBackoff {
+		t.Errorf("Expected default backoffs, got %+v", client.retry)
+	}
+	if delay, ok := client.retry.backoff(1, 0); !ok || delay <= 0 {
+		t.Errorf("Expected a positive delay, got %s", delay)
+	}
+
+	// explicit values and disabled retries are left alone
+	client, _ = NewClientWithOptions(TestCustomerId, TestAPIKey, WithRetryPolicy(TestRetryPolicy))
+	if client.retry != TestRetryPolicy {
+		t.Errorf("Expected %+v, got %+v", TestRetryPolicy, client.retry)
+	}
+	client, _ = NewClientWithOptions(TestCustomerId, TestAPIKey, WithRetryPolicy(NoRetries))
+	if client.retry != NoRetries {
+		t.Errorf("Expected %+v, got %+v", NoRetries, client.retry)
+	}
+
+	// the legacy constructor keeps sending requests once, the options one retries
+	if client := NewClient(TestCustomerId, TestAPIKey, TestTimeout); client.retry != NoRetries {
+		t.Errorf("Expected NewClient not to retry, got %+v", client.retry)
+	}
+	if client, _ := NewClientWithOptions(TestCustomerId, TestAPIKey); client.retry != DefaultRetryPolicy {
+		t.Errorf("Expected %+v, got %+v", DefaultRetryPolicy, client.retry)
+	}
+
+}
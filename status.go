@@ -0,0 +1,148 @@
+package ghostmates
+
+import (
+	"errors"
+	"fmt"
+)
+
+type (
+	// Status is where a delivery is in its lifecycle.  Deliveries progress
+	// pending, pickup, pickup_complete, dropoff and end delivered, canceled
+	// or returned.
+	Status string
+
+	// TransitionError describes an impossible status change between two
+	// events for the same delivery.
+	TransitionError struct {
+		EventID    string
+		DeliveryID string
+		From, To   Status
+	}
+)
+
+const (
+	StatusPending        Status = "pending"         // We've accepted the delivery and will be assigning it to a courier.
+	StatusPickup         Status = "pickup"          // Courier is assigned and is en route to pick up the items
+	StatusPickupComplete Status = "pickup_complete" // Courier has picked up the items
+	StatusDropoff        Status = "dropoff"         // Courier is moving towards the dropoff
+	StatusCanceled       Status = "canceled"        // Items won't be delivered. Deliveries are either canceled by the customer or by our customer service team.
+	StatusDelivered      Status = "delivered"       // Items were delivered successfully.
+	StatusReturned       Status = "returned"        // The delivery was canceled and a new job created to return items to sender. (See related_deliveries in delivery object.)
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid status transition")
+
+	// position of each status in a delivery's progression, terminal
+	// statuses share the last position
+	statusOrder = map[Status]int{
+		StatusPending:        1,
+		StatusPickup:         2,
+		StatusPickupComplete: 3,
+		StatusDropoff:        4,
+		StatusDelivered:      5,
+		StatusCanceled:       5,
+		StatusReturned:       5,
+	}
+)
+
+// Valid reports whether s is a status the api is known to send.
+func (s Status) Valid() bool {
+	_, ok := statusOrder[s]
+	return ok
+}
+
+// Order returns the position of s in a delivery's progression, from 1 for
+// pending to 5 for the terminal statuses, or 0 for an unknown status.
+func (s Status) Order() int {
+	return statusOrder[s]
+}
+
+// Before reports whether s comes earlier in a delivery's progression than o.
+func (s Status) Before(o Status) bool {
+	return s.Valid() && o.Valid() && s.Order() < o.Order()
+}
+
+// IsTerminal reports whether a delivery in status s will not change again.
+func (s Status) IsTerminal() bool {
+	return s == StatusDelivered || s == StatusCanceled || s == StatusReturned
+}
+
+// CanCancel reports whether a delivery in status s can still be canceled,
+// ie. the courier hasn't picked up the items yet.
+func (s Status) CanCancel() bool {
+	return s == StatusPending || s == StatusPickup
+}
+
+// CanReturn reports whether a delivery in status s can be returned to the
+// pickup location, ie. the courier has the items but hasn't delivered them.
+func (s Status) CanReturn() bool {
+	return s == StatusPickupComplete || s == StatusDropoff
+}
+
+// CanTransition reports whether a delivery can move from s to next.
+// Repeats are allowed, and so are skipped steps since webhook events can be
+// lost.  The only step backwards is a courier dropping a delivery before
+// picking it up, which puts it back to pending.
+func (s Status) CanTransition(next Status) bool {
+
+	switch {
+	case !s.Valid() || !next.Valid():
+		return false
+	case s == next:
+		return true
+	case s.IsTerminal():
+		return false
+	case next == StatusCanceled:
+		return true
+	case next == StatusReturned:
+		return s.CanReturn()
+	case s == StatusPickup && next == StatusPending:
+		return true
+	}
+
+	return s.Before(next)
+
+}
+
+// ValidateTransitions checks the status changes in events, which must be in
+// the order they were sent, tracking each delivery separately.  It returns
+// a *TransitionError for every impossible change, joined, or nil.
+func ValidateTransitions(events []*DeliveryStatusEvent) error {
+
+	var (
+		errs []error
+		last = map[string]Status{}
+	)
+
+	for _, e := range events {
+
+		if prev, ok := last[e.DeliveryID]; ok && !prev.CanTransition(e.Status) {
+			errs = append(errs, &TransitionError{EventID: e.ID, DeliveryID: e.DeliveryID, From: prev, To: e.Status})
+			// keep comparing against the last believable status
+			continue
+		}
+
+		if !e.Status.Valid() {
+			errs = append(errs, &TransitionError{EventID: e.ID, DeliveryID: e.DeliveryID, To: e.Status})
+			continue
+		}
+
+		last[e.DeliveryID] = e.Status
+
+	}
+
+	return errors.Join(errs...)
+
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.From) == 0 {
+		return fmt.Sprintf("%s: event %s for %s has unknown status %q", ErrInvalidTransition, e.EventID, e.DeliveryID, e.To)
+	}
+	return fmt.Sprintf("%s: event %s moves %s from %s to %s", ErrInvalidTransition, e.EventID, e.DeliveryID, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
@@ -0,0 +1,100 @@
+package ghostmates
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+
+	for s, expected := range map[Status][3]bool{
+		StatusPending:        {false, true, false},
+		StatusPickup:         {false, true, false},
+		StatusPickupComplete: {false, false, true},
+		StatusDropoff:        {false, false, true},
+		StatusDelivered:      {true, false, false},
+		StatusCanceled:       {true, false, false},
+		StatusReturned:       {true, false, false},
+		"lost":               {false, false, false},
+	} {
+		if got := [3]bool{s.IsTerminal(), s.CanCancel(), s.CanReturn()}; got != expected {
+			t.Errorf("%s: expected terminal, cancel, return %v, got %v", s, expected, got)
+		}
+	}
+
+	if !StatusPickup.Before(StatusDropoff) || StatusDelivered.Before(StatusPickup) || Status("lost").Before(StatusDelivered) {
+		t.Errorf("Unexpected progression order")
+	}
+
+	for _, c := range []struct {
+		from, to Status
+		expected bool
+	}{
+		{StatusPending, StatusPickup, true},
+		{StatusPickup, StatusDropoff, true}, // missed pickup_complete
+		{StatusDropoff, StatusDropoff, true},
+		{StatusPickup, StatusPending, true}, // courier dropped it
+		{StatusPickup, StatusCanceled, true},
+		{StatusDropoff, StatusReturned, true},
+		{StatusPending, StatusReturned, false},
+		{StatusDelivered, StatusPickup, false},
+		{StatusCanceled, StatusDelivered, false},
+		{StatusDropoff, StatusPickupComplete, false},
+		{StatusPending, "lost", false},
+	} {
+		if got := c.from.CanTransition(c.to); got != c.expected {
+			t.Errorf("%s -> %s: expected %v, got %v", c.from, c.to, c.expected, got)
+		}
+	}
+
+}
+
+func TestValidateTransitions(t *testing.T) {
+
+	// the recorded delivery_status events are a clean run
+	var events []*DeliveryStatusEvent
+	for _, payload := range TestDeliveryEventsJSON {
+		var k struct {
+			Status Status `json:"status"`
+		}
+		json.Unmarshal([]byte(payload), &k)
+		if len(k.Status) == 0 {
+			continue
+		}
+		e := &DeliveryStatusEvent{}
+		if err := json.Unmarshal([]byte(payload), e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	if len(events) < 4 {
+		t.Fatalf("Expected recorded status events, got %d", len(events))
+	}
+	if err := ValidateTransitions(events); err != nil {
+		t.Errorf("Expected recorded events to be valid, got %v", err)
+	}
+
+	events = []*DeliveryStatusEvent{
+		{ID: "evt_1", DeliveryID: "del_1", Status: StatusPickup},
+		{ID: "evt_2", DeliveryID: "del_2", Status: StatusPending},
+		{ID: "evt_3", DeliveryID: "del_1", Status: StatusDelivered},
+		{ID: "evt_4", DeliveryID: "del_1", Status: StatusPickup},
+		{ID: "evt_5", DeliveryID: "del_2", Status: "lost"},
+		{ID: "evt_6", DeliveryID: "del_2", Status: StatusCanceled},
+	}
+
+	err := ValidateTransitions(events)
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Expected ErrInvalidTransition, got %v", err)
+	}
+
+	var te *TransitionError
+	if !errors.As(err, &te) || te.EventID != "evt_4" || te.From != StatusDelivered || te.To != StatusPickup {
+		t.Errorf("Expected delivered -> pickup to be flagged first, got %+v", te)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("Expected 2 invalid transitions, got %d: %v", n, err)
+	}
+
+}
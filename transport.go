@@ -1,18 +1,102 @@
 package ghostmates
 
-import "net/http"
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
 
 type (
 	postmatesTransport func(req *http.Request) (*http.Response, error)
+
+	// Middleware wraps the client's transport to add cross-cutting behavior
+	// such as logging, metrics or tracing headers.  Requests reaching
+	// middleware are already addressed to the api and authenticated.
+	Middleware func(next http.RoundTripper) http.RoundTripper
 )
 
 func (pt postmatesTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return pt(req)
 }
 
-func (pt postmatesTransport) CancelRequest(req *http.Request) {
-	type canceler interface {
-		CancelRequest(*http.Request)
+// WithMiddleware adds mw to the client's transport.  Middleware runs in the
+// order registered across all calls to WithMiddleware.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) error {
+		c.middleware = append(c.middleware, mw...)
+		return nil
+	}
+}
+
+// chain wraps rt in mw so that mw[0] sees requests first
+func chain(rt http.RoundTripper, mw ...Middleware) http.RoundTripper {
+	for i := len(mw) - 1; i >= 0; i-- {
+		rt = mw[i](rt)
 	}
-	http.DefaultTransport.(canceler).CancelRequest(req)
+	return rt
+}
+
+// postmatesMiddleware is the built-in layer that points requests at the
+// configured base url and sets the version and auth headers
+func (c *Client) postmatesMiddleware(next http.RoundTripper) http.RoundTripper {
+	return postmatesTransport(func(req *http.Request) (*http.Response, error) {
+
+		// RoundTrippers must not modify the caller's request
+		req = req.Clone(req.Context())
+
+		req.Host = c.base.Host
+		req.URL.Host = c.base.Host
+		req.URL.Scheme = c.base.Scheme
+		if prefix := strings.TrimRight(c.base.Path, "/"); len(prefix) > 0 {
+			req.URL.Path = prefix + req.URL.Path
+			if len(req.URL.RawPath) > 0 {
+				req.URL.RawPath = prefix + req.URL.RawPath
+			}
+		}
+		req.Header.Set("X-Postmates-Version", c.version)
+		if len(c.user_agent) > 0 {
+			req.Header.Set("User-Agent", c.user_agent)
+		}
+
+		key, err := c.credentials.APIKey(req.Context())
+		if err != nil {
+			return nil, fmt.Errorf("unable to get postmates credentials: %w", err)
+		}
+		req.SetBasicAuth(key, "")
+
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+
+		// the key may have been rotated under us, retry once with a fresh one
+		refresher, ok := c.credentials.(CredentialsRefresher)
+		if !ok || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			return resp, nil
+		}
+		if err := refresher.Refresh(req.Context()); err != nil {
+			return resp, nil
+		}
+		fresh, err := c.credentials.APIKey(req.Context())
+		if err != nil || fresh == key {
+			return resp, nil
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		req.SetBasicAuth(fresh, "")
+
+		return next.RoundTrip(req)
+
+	})
 }
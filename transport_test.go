@@ -0,0 +1,71 @@
+package ghostmates
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+
+	var (
+		order  []string
+		traced string
+	)
+
+	named := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return postmatesTransport(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				if _, _, ok := req.BasicAuth(); !ok || !strings.HasPrefix(req.URL.Path, "/v1/customers/") {
+					t.Errorf("%s: expected an authenticated api request, got %s", name, req.URL)
+				}
+				req.Header.Set("X-Trace-"+name, "1")
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		traced = req.Header.Get("X-Trace-a") + req.Header.Get("X-Trace-b") + req.Header.Get("X-Trace-c")
+		w.Write([]byte(`{"kind":"delivery","id":"del_test"}`))
+	}, WithMiddleware(named("a"), named("b")), WithMiddleware(named("c")))
+
+	if _, err := client.GetDelivery("del_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(order, ",") != "a,b,c" {
+		t.Errorf("Expected middleware to run in registration order, got %v", order)
+	}
+	if traced != "111" {
+		t.Errorf("Expected every middleware header to reach the server, got %q", traced)
+	}
+
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+
+	// a middleware can answer requests itself, eg. replaying recorded responses
+	replay := func(next http.RoundTripper) http.RoundTripper {
+		return postmatesTransport(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Status:     "404 NOT FOUND",
+				Header:     http.Header{},
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		})
+	}
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		t.Errorf("Expected the request to be answered by middleware")
+	}, WithMiddleware(replay))
+
+	_, err := client.GetDelivery("del_test")
+	if e, ok := err.(*Error); !ok || e.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 *Error, got %v", err)
+	}
+
+}
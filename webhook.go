@@ -14,8 +14,14 @@ type (
 	Webhook struct {
 		Handler http.HandlerFunc
 		Events  Events
+
+		log  logging
+		mode Mode
 	}
 
+	// WebhookOption configures a Webhook created with NewWebhook.
+	WebhookOption func(wh *Webhook)
+
 	Events struct {
 		DeliveryStatus   <-chan *DeliveryStatusEvent
 		DeliveryDeadline <-chan *DeliveryDeadlineEvent
@@ -31,7 +37,7 @@ type (
 		DeliveryID string     `json:"delivery_id"`
 		LiveMode   bool       `json:"live_mode"`
 
-		Status   string    `json:"status"`
+		Status   Status    `json:"status"`
 		Delivery *Delivery `json:"data"`
 	}
 
@@ -67,7 +73,7 @@ type (
 		DeliveryID string     `json:"delivery_id"`
 		LiveMode   bool       `json:"live_mode"`
 
-		Status   string    `json:"status"`
+		Status   Status    `json:"status"`
 		Delivery *Delivery `json:"data"`
 	}
 )
@@ -87,7 +93,7 @@ var (
 	ErrUnsupportedEventKind = errors.New("unsupported event kind")
 )
 
-func NewWebhook() *Webhook {
+func NewWebhook(opts ...WebhookOption) *Webhook {
 
 	DeliveryStatusEventChan := make(chan *DeliveryStatusEvent, DefaultBufferLength)
 	DeliveryDeadlineEventChan := make(chan *DeliveryDeadlineEvent, DefaultBufferLength)
@@ -101,6 +107,11 @@ func NewWebhook() *Webhook {
 			CourierUpdate:    CourierUpdateEventChan,
 			DeliveryReturn:   DeliveryReturnEventChan,
 		},
+		log: defaultLogging(),
+	}
+
+	for _, opt := range opts {
+		opt(wh)
 	}
 
 	wh.Handler = func(w http.ResponseWriter, req *http.Request) {
@@ -118,6 +129,7 @@ func NewWebhook() *Webhook {
 				Kind string `json:"kind"`
 			}
 			if err := json.Unmarshal(data, &k); err != nil {
+				wh.log.event(req.Context(), "", "", "", false, nil, err)
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
@@ -126,9 +138,16 @@ func NewWebhook() *Webhook {
 			case DeliveryStatusEventKind:
 				v := &DeliveryStatusEvent{}
 				if err := json.Unmarshal(data, v); err != nil {
+					wh.log.event(req.Context(), k.Kind, "", "", false, nil, err)
 					http.Error(w, err.Error(), http.StatusBadRequest)
 					return
 				}
+				if err := wh.mode.checkEvent(v.ID, v.LiveMode, v.Delivery); err != nil {
+					wh.log.event(req.Context(), k.Kind, v.ID, v.DeliveryID, v.LiveMode, v.Delivery, err)
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				wh.log.event(req.Context(), k.Kind, v.ID, v.DeliveryID, v.LiveMode, v.Delivery, nil)
 				select {
 				case DeliveryStatusEventChan <- v:
 				default:
@@ -137,9 +156,16 @@ func NewWebhook() *Webhook {
 			case DeliveryDeadlineEventKind:
 				v := &DeliveryDeadlineEvent{}
 				if err := json.Unmarshal(data, v); err != nil {
+					wh.log.event(req.Context(), k.Kind, "", "", false, nil, err)
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if err := wh.mode.checkEvent(v.ID, v.LiveMode, v.Delivery); err != nil {
+					wh.log.event(req.Context(), k.Kind, v.ID, v.DeliveryID, v.LiveMode, v.Delivery, err)
 					http.Error(w, err.Error(), http.StatusBadRequest)
 					return
 				}
+				wh.log.event(req.Context(), k.Kind, v.ID, v.DeliveryID, v.LiveMode, v.Delivery, nil)
 				select {
 				case DeliveryDeadlineEventChan <- v:
 				default:
@@ -148,9 +174,16 @@ func NewWebhook() *Webhook {
 			case CourierUpdateEventKind:
 				v := &CourierUpdateEvent{}
 				if err := json.Unmarshal(data, v); err != nil {
+					wh.log.event(req.Context(), k.Kind, "", "", false, nil, err)
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if err := wh.mode.checkEvent(v.ID, v.LiveMode, v.Delivery); err != nil {
+					wh.log.event(req.Context(), k.Kind, v.ID, v.DeliveryID, v.LiveMode, v.Delivery, err)
 					http.Error(w, err.Error(), http.StatusBadRequest)
 					return
 				}
+				wh.log.event(req.Context(), k.Kind, v.ID, v.DeliveryID, v.LiveMode, v.Delivery, nil)
 				select {
 				case CourierUpdateEventChan <- v:
 				default:
@@ -159,15 +192,23 @@ func NewWebhook() *Webhook {
 			case DeliveryReturnEventKind:
 				v := &DeliveryReturnEvent{}
 				if err := json.Unmarshal(data, v); err != nil {
+					wh.log.event(req.Context(), k.Kind, "", "", false, nil, err)
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if err := wh.mode.checkEvent(v.ID, v.LiveMode, v.Delivery); err != nil {
+					wh.log.event(req.Context(), k.Kind, v.ID, v.DeliveryID, v.LiveMode, v.Delivery, err)
 					http.Error(w, err.Error(), http.StatusBadRequest)
 					return
 				}
+				wh.log.event(req.Context(), k.Kind, v.ID, v.DeliveryID, v.LiveMode, v.Delivery, nil)
 				select {
 				case DeliveryReturnEventChan <- v:
 				default:
 					// writes should not block, discard overflow
 				}
 			default:
+				wh.log.event(req.Context(), k.Kind, "", "", false, nil, ErrUnsupportedEventKind)
 				http.Error(w, ErrUnsupportedEventKind.Error(), http.StatusBadRequest)
 				return
 			}
@@ -0,0 +1,179 @@
+package ghostmates
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+type (
+	// Zone is an area postmates delivers within, decoded from a GeoJSON
+	// Feature with a Polygon or MultiPolygon geometry.  Features with any
+	// other geometry, or none, have no polygons and contain nothing.
+	Zone struct {
+		ID       string
+		Name     string
+		Polygons []Polygon
+	}
+
+	// Zones are all the areas postmates delivers within.
+	Zones []*Zone
+
+	// Polygon is an outer ring followed by any holes cut out of it.  Rings
+	// are closed, the last point repeats the first.
+	Polygon [][]Location
+
+	// position is a GeoJSON [lng, lat] pair
+	position [2]float64
+
+	featureCollection struct {
+		Type     string  `json:"type"`
+		Features []*Zone `json:"features"`
+	}
+)
+
+func (c *Client) GetDeliveryZones() (Zones, error) {
+	return c.GetDeliveryZonesContext(context.Background())
+}
+
+func (c *Client) GetDeliveryZonesContext(ctx context.Context) (Zones, error) {
+
+	// GET /v1/delivery_zones
+	// Returns: a list of GeoJSON FeatureCollections
+
+	var raw json.RawMessage
+	if err := c.do(ctx, readCall, "GET", "/v1/delivery_zones", nil, &raw); err != nil {
+		return nil, err
+	}
+
+	// accept a lone FeatureCollection as well as a list of them
+	var fcs []*featureCollection
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		raw = append(append([]byte("["), raw...), ']')
+	}
+	if err := json.Unmarshal(raw, &fcs); err != nil {
+		return nil, &DecodeError{Err: err}
+	}
+
+	var zones Zones
+	for _, fc := range fcs {
+		zones = append(zones, fc.Features...)
+	}
+
+	return zones, nil
+
+}
+
+func (z *Zone) UnmarshalJSON(data []byte) error {
+
+	var f struct {
+		Properties struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"properties"`
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	z.ID, z.Name, z.Polygons = f.Properties.ID, f.Properties.Name, nil
+
+	switch f.Geometry.Type {
+	case "Polygon":
+		var p [][]position
+		if err := json.Unmarshal(f.Geometry.Coordinates, &p); err != nil {
+			return err
+		}
+		z.Polygons = append(z.Polygons, newPolygon(p))
+	case "MultiPolygon":
+		var mp [][][]position
+		if err := json.Unmarshal(f.Geometry.Coordinates, &mp); err != nil {
+			return err
+		}
+		for _, p := range mp {
+			z.Polygons = append(z.Polygons, newPolygon(p))
+		}
+	default:
+		// GeoJSON allows a null geometry, and points or lines cover no
+		// area, so the zone contains nothing rather than failing every zone
+	}
+
+	return nil
+
+}
+
+func newPolygon(rings [][]position) Polygon {
+	p := make(Polygon, len(rings))
+	for i, ring := range rings {
+		p[i] = make([]Location, len(ring))
+		for j, pos := range ring {
+			p[i][j] = Location{Lat: pos[1], Lng: pos[0]}
+		}
+	}
+	return p
+}
+
+// Contains reports whether l is inside any of the zones.
+func (zs Zones) Contains(l Location) bool {
+	return zs.Find(l) != nil
+}
+
+// Find returns the first zone containing l, or nil.
+func (zs Zones) Find(l Location) *Zone {
+	for _, z := range zs {
+		if z.Contains(l) {
+			return z
+		}
+	}
+	return nil
+}
+
+// Contains reports whether l is inside any of the zone's polygons.
+func (z *Zone) Contains(l Location) bool {
+	for _, p := range z.Polygons {
+		if p.Contains(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether l is inside the outer ring and outside every
+// hole.  Points exactly on an edge may fall either way.
+func (p Polygon) Contains(l Location) bool {
+
+	if len(p) == 0 || !inRing(p[0], l) {
+		return false
+	}
+
+	for _, hole := range p[1:] {
+		if inRing(hole, l) {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+// inRing casts a ray east from l and counts the edges it crosses, an odd
+// count is inside.  Zones are small enough to treat lat/lng as planar.
+func inRing(ring []Location, l Location) bool {
+
+	in := false
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > l.Lat) != (b.Lat > l.Lat) &&
+			l.Lng < a.Lng+(l.Lat-a.Lat)*(b.Lng-a.Lng)/(b.Lat-a.Lat) {
+			in = !in
+		}
+	}
+
+	return in
+
+}
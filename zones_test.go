@@ -0,0 +1,69 @@
+package ghostmates
+
+import (
+	"net/http"
+	"testing"
+)
+
+// a square around lower manhattan with a hole over the financial district,
+// a separate square over williamsburg and a zone with no geometry
+const TestDeliveryZones = `[{"type":"FeatureCollection","features":[
+	{"type":"Feature","properties":{"id":"manhattan","name":"Manhattan"},"geometry":{"type":"Polygon","coordinates":[
+		[[-74.02,40.70],[-73.97,40.70],[-73.97,40.77],[-74.02,40.77],[-74.02,40.70]],
+		[[-74.015,40.70],[-74.00,40.70],[-74.00,40.71],[-74.015,40.71],[-74.015,40.70]]
+	]}},
+	{"type":"Feature","properties":{"id":"brooklyn","name":"Brooklyn"},"geometry":{"type":"MultiPolygon","coordinates":[
+		[[[-73.97,40.70],[-73.94,40.70],[-73.94,40.73],[-73.97,40.73],[-73.97,40.70]]],
+		[[[-73.99,40.67],[-73.97,40.67],[-73.97,40.69],[-73.99,40.69],[-73.99,40.67]]]
+	]}},
+	{"type":"Feature","properties":{"id":"queens","name":"Queens"},"geometry":null}
+]}]`
+
+func TestGetDeliveryZones(t *testing.T) {
+
+	client := newTestClient(t, func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v1/delivery_zones" {
+			t.Errorf("Unexpected path %q", req.URL.Path)
+		}
+		w.Write([]byte(TestDeliveryZones))
+	})
+
+	zones, err := client.GetDeliveryZones()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 3 || zones[0].Name != "Manhattan" || len(zones[1].Polygons) != 2 || len(zones[2].Polygons) != 0 {
+		t.Fatalf("Unexpected zones %+v", zones)
+	}
+
+	if zones[2].Contains(Location{Lat: 40.74, Lng: -73.87}) {
+		t.Errorf("Expected a zone without geometry to contain nothing")
+	}
+
+	// coordinates are [lng, lat]
+	if l := zones[0].Polygons[0][0][0]; l.Lat != 40.70 || l.Lng != -74.02 {
+		t.Errorf("Unexpected first point %+v", l)
+	}
+
+	for _, c := range []struct {
+		name     string
+		l        Location
+		expected string
+	}{
+		{"pickup", Location{Lat: TestPickupLat, Lng: TestPickupLng}, "manhattan"},
+		{"dropoff", Location{Lat: TestDropoffLat, Lng: TestDropoffLng}, "manhattan"},
+		{"hole", Location{Lat: 40.705, Lng: -74.01}, ""},
+		{"williamsburg", Location{Lat: 40.71, Lng: -73.95}, "brooklyn"},
+		{"park slope", Location{Lat: 40.68, Lng: -73.98}, "brooklyn"},
+		{"hoboken", Location{Lat: 40.74, Lng: -74.03}, ""},
+	} {
+		var id string
+		if z := zones.Find(c.l); z != nil {
+			id = z.ID
+		}
+		if id != c.expected || zones.Contains(c.l) != (len(c.expected) > 0) {
+			t.Errorf("%s: expected zone %q, got %q", c.name, c.expected, id)
+		}
+	}
+
+}